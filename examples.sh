@@ -1,6 +1,30 @@
 # Automatic increase next version based on git log:
 svu next
 
+# Create a git tag with the next version:
+svu tag
+
+# Write the next version in the configured files:
+svu bump --write
+
+# Write the next version in the configured files, commit and tag them:
+svu release
+
+# Check the commits since the current tag are conventional commits:
+svu lint
+
+# Lint commit messages, and verify pushed tags, in git hooks:
+svu hooks install --pre-push
+
+# Release notes of the next version:
+svu changelog
+
+# Next version of every Go module in the repository:
+svu go
+
+# Explain how the next version is computed:
+svu explain
+
 # Increase patch, minor, major:
 svu patch
 svu minor
@@ -8,3 +32,6 @@ svu major
 
 # Show current version:
 svu current
+
+# Final version of the current prerelease:
+svu promote
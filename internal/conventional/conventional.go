@@ -0,0 +1,163 @@
+// Package conventional parses commit messages following the Conventional
+// Commits 1.0 specification: https://www.conventionalcommits.org/en/v1.0.0/
+package conventional
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrNotConventional is returned for messages whose title is not a
+	// conventional commit header, e.g. "update readme".
+	ErrNotConventional = errors.New("not a conventional commit")
+	// ErrMalformedScope is returned for headers whose scope is empty, not
+	// closed, has nested parentheses, or is padded with spaces.
+	ErrMalformedScope = errors.New("malformed scope")
+)
+
+// footerToken matches the first line of a footer: a token, which has no
+// spaces except for BREAKING CHANGE, followed by ": " or " #".
+var footerToken = regexp.MustCompile(`^(BREAKING CHANGE|[\w-]+)(: | #)`)
+
+// Commit is a parsed conventional commit.
+type Commit struct {
+	Type  string
+	Scope string
+	// Breaking is set by a "!" before the ":" of the header, or by a
+	// BREAKING CHANGE footer.
+	Breaking    bool
+	Description string
+	// Body is the body of the commit, without its footers.
+	Body    string
+	Footers []Footer
+}
+
+// Footer is a git trailer like footer, e.g. "Refs: #123".
+type Footer struct {
+	Token string
+	// Value is the value of the footer, without the separator, except for
+	// the "#" of " #" separators, e.g. "#123" for "Refs #123".
+	Value string
+}
+
+// Error is the error returned by Parse.
+type Error struct {
+	// Err is ErrNotConventional or ErrMalformedScope.
+	Err error
+	// Reason describes what is wrong with the message.
+	Reason string
+}
+
+func (e *Error) Error() string { return e.Err.Error() + ": " + e.Reason }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Parse parses the given commit title, the first line of its message, and
+// body, the rest of it.
+func Parse(title, body string) (Commit, error) {
+	var c Commit
+	title = strings.TrimSpace(title)
+
+	i := strings.IndexFunc(title, func(r rune) bool {
+		return !isTypeChar(r)
+	})
+	if i < 0 {
+		i = len(title)
+	}
+	if i == 0 {
+		return c, &Error{ErrNotConventional, "the title does not start with a type"}
+	}
+	c.Type, title = title[:i], title[i:]
+
+	if rest, ok := strings.CutPrefix(title, "("); ok {
+		end := strings.IndexAny(rest, "()")
+		if end < 0 || rest[end] != ')' {
+			return c, &Error{ErrMalformedScope, "the scope is not closed, or has nested parentheses"}
+		}
+		scope := rest[:end]
+		switch strings.TrimSpace(scope) {
+		case "":
+			return c, &Error{ErrMalformedScope, "the scope is empty"}
+		case scope:
+		default:
+			return c, &Error{ErrMalformedScope, "the scope has leading or trailing spaces"}
+		}
+		c.Scope, title = scope, rest[end+1:]
+	}
+
+	if rest, ok := strings.CutPrefix(title, "!"); ok {
+		c.Breaking, title = true, rest
+	}
+	description, ok := strings.CutPrefix(title, ": ")
+	if !ok {
+		return c, &Error{ErrNotConventional, `the type is not followed by ": "`}
+	}
+	c.Description = strings.TrimSpace(description)
+	if c.Description == "" {
+		return c, &Error{ErrNotConventional, "the description is empty"}
+	}
+
+	c.Body, c.Footers = splitFooters(body)
+	for _, f := range c.Footers {
+		if f.Token == "BREAKING CHANGE" || f.Token == "BREAKING-CHANGE" {
+			c.Breaking = true
+		}
+	}
+	return c, nil
+}
+
+// ParseMessage parses the given commit message.
+func ParseMessage(message string) (Commit, error) {
+	title, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return Parse(title, body)
+}
+
+func isTypeChar(r rune) bool {
+	return r == '_' || r == '-' ||
+		(r >= 'a' && r <= 'z') ||
+		(r >= 'A' && r <= 'Z') ||
+		(r >= '0' && r <= '9')
+}
+
+// splitFooters splits the given body in the body itself and its footers.
+//
+// The footers are the trailing paragraphs of the body that start with a
+// footer token.
+// A footer value spans every line until the next footer token.
+func splitFooters(body string) (string, []Footer) {
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	start := -1
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) == "" || (i > 0 && strings.TrimSpace(lines[i-1]) != "") {
+			continue // not the start of a paragraph
+		}
+		if !footerToken.MatchString(lines[i]) {
+			break
+		}
+		start = i
+	}
+	if start < 0 {
+		return strings.TrimSpace(body), nil
+	}
+
+	var footers []Footer
+	for _, line := range lines[start:] {
+		m := footerToken.FindStringSubmatch(line)
+		if m == nil {
+			last := &footers[len(footers)-1]
+			last.Value += "\n" + line
+			continue
+		}
+		value := line[len(m[0]):]
+		if m[2] == " #" {
+			value = "#" + value
+		}
+		footers = append(footers, Footer{Token: m[1], Value: value})
+	}
+	for i := range footers {
+		footers[i].Value = strings.TrimSpace(footers[i].Value)
+	}
+	return strings.TrimSpace(strings.Join(lines[:start], "\n")), footers
+}
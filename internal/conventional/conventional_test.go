@@ -0,0 +1,243 @@
+package conventional
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSpec(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		message  string
+		expected Commit
+	}{
+		{
+			name:    "description and breaking change footer",
+			message: "feat: allow provided config object to extend other configs\n\nBREAKING CHANGE: `extends` key in config file is now used for extending other config files",
+			expected: Commit{
+				Type:        "feat",
+				Breaking:    true,
+				Description: "allow provided config object to extend other configs",
+				Footers: []Footer{
+					{Token: "BREAKING CHANGE", Value: "`extends` key in config file is now used for extending other config files"},
+				},
+			},
+		},
+		{
+			name:    "! to draw attention to breaking change",
+			message: "feat!: send an email to the customer when a product is shipped",
+			expected: Commit{
+				Type:        "feat",
+				Breaking:    true,
+				Description: "send an email to the customer when a product is shipped",
+			},
+		},
+		{
+			name:    "scope and ! to draw attention to breaking change",
+			message: "feat(api)!: send an email to the customer when a product is shipped",
+			expected: Commit{
+				Type:        "feat",
+				Scope:       "api",
+				Breaking:    true,
+				Description: "send an email to the customer when a product is shipped",
+			},
+		},
+		{
+			name:    "both ! and BREAKING CHANGE footer",
+			message: "chore!: drop support for Node 6\n\nBREAKING CHANGE: use JavaScript features not available in Node 6.",
+			expected: Commit{
+				Type:        "chore",
+				Breaking:    true,
+				Description: "drop support for Node 6",
+				Footers: []Footer{
+					{Token: "BREAKING CHANGE", Value: "use JavaScript features not available in Node 6."},
+				},
+			},
+		},
+		{
+			name:    "no body",
+			message: "docs: correct spelling of CHANGELOG",
+			expected: Commit{
+				Type:        "docs",
+				Description: "correct spelling of CHANGELOG",
+			},
+		},
+		{
+			name:    "scope",
+			message: "feat(lang): add Polish language",
+			expected: Commit{
+				Type:        "feat",
+				Scope:       "lang",
+				Description: "add Polish language",
+			},
+		},
+		{
+			name:    "multi-paragraph body and multiple footers",
+			message: "fix: prevent racing of requests\n\nIntroduce a request id and a reference to latest request. Dismiss\nincoming responses other than from latest request.\n\nRemove timeouts which were used to mitigate the racing issue but are\nobsolete now.\n\nReviewed-by: Z\nRefs: #123",
+			expected: Commit{
+				Type:        "fix",
+				Description: "prevent racing of requests",
+				Body:        "Introduce a request id and a reference to latest request. Dismiss\nincoming responses other than from latest request.\n\nRemove timeouts which were used to mitigate the racing issue but are\nobsolete now.",
+				Footers: []Footer{
+					{Token: "Reviewed-by", Value: "Z"},
+					{Token: "Refs", Value: "#123"},
+				},
+			},
+		},
+		{
+			name:    "revert with hash separator footer",
+			message: "revert: let us never again speak of the noodle incident\n\nRefs #676104e",
+			expected: Commit{
+				Type:        "revert",
+				Description: "let us never again speak of the noodle incident",
+				Footers:     []Footer{{Token: "Refs", Value: "#676104e"}},
+			},
+		},
+		{
+			name:    "BREAKING-CHANGE is a synonym of BREAKING CHANGE",
+			message: "refactor: foo\n\nBREAKING-CHANGE: bar",
+			expected: Commit{
+				Type:        "refactor",
+				Breaking:    true,
+				Description: "foo",
+				Footers:     []Footer{{Token: "BREAKING-CHANGE", Value: "bar"}},
+			},
+		},
+		{
+			name:    "multi-line footer value",
+			message: "fix: foo\n\nBREAKING CHANGE: the first line\nand the second one\nSigned-off-by: Foo <foo@example.com>",
+			expected: Commit{
+				Type:        "fix",
+				Breaking:    true,
+				Description: "foo",
+				Footers: []Footer{
+					{Token: "BREAKING CHANGE", Value: "the first line\nand the second one"},
+					{Token: "Signed-off-by", Value: "Foo <foo@example.com>"},
+				},
+			},
+		},
+		{
+			name:    "types are case insensitive, BREAKING CHANGE is not",
+			message: "FEAT: foo\n\nbreaking change: bar",
+			expected: Commit{
+				Type:        "FEAT",
+				Description: "foo",
+				Body:        "breaking change: bar",
+			},
+		},
+		{
+			name:    "! after the header is not breaking",
+			message: "docs: explain why foo!: bar happens",
+			expected: Commit{
+				Type:        "docs",
+				Description: "explain why foo!: bar happens",
+			},
+		},
+		{
+			name:    "footers only in the last paragraphs",
+			message: "fix: foo\n\nBREAKING CHANGE: not a footer, the body follows\n\nthe body",
+			expected: Commit{
+				Type:        "fix",
+				Description: "foo",
+				Body:        "BREAKING CHANGE: not a footer, the body follows\n\nthe body",
+			},
+		},
+		{
+			name:    "type is the whole word",
+			message: "notfeat: x",
+			expected: Commit{
+				Type:        "notfeat",
+				Description: "x",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseMessage(tt.message)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, c)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for title, expected := range map[string]error{
+		"update readme":            ErrNotConventional,
+		"":                         ErrNotConventional,
+		": foo":                    ErrNotConventional,
+		"feat:foo":                 ErrNotConventional,
+		"feat: ":                   ErrNotConventional,
+		"feat :foo":                ErrNotConventional,
+		"Merge branch 'main'":      ErrNotConventional,
+		"fixup! feat: foo":         ErrNotConventional,
+		"foo bar: baz":             ErrNotConventional,
+		"feat(): foo":              ErrMalformedScope,
+		"feat( ): foo":             ErrMalformedScope,
+		"feat( api): foo":          ErrMalformedScope,
+		"feat(api: foo":            ErrMalformedScope,
+		"feat(a(b)): foo":          ErrMalformedScope,
+		"feat(api)(cli): foo":      ErrNotConventional,
+		"feat(api) !: foo":         ErrNotConventional,
+		"feat!!: foo":              ErrNotConventional,
+		"feat(api)!:foo":           ErrNotConventional,
+		"feat(api)! : description": ErrNotConventional,
+	} {
+		t.Run(title, func(t *testing.T) {
+			_, err := Parse(title, "")
+			require.ErrorIs(t, err, expected)
+		})
+	}
+
+	_, err := Parse("feat(): foo", "")
+	require.EqualError(t, err, "malformed scope: the scope is empty")
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"feat: foo",
+		"feat(api)!: foo\n\nbody\n\nBREAKING CHANGE: bar\nRefs #1",
+		"fix(scope): foo\n\nReviewed-by: Z\n\nmore",
+		"docs: explain why foo!: bar happens",
+		"feat(: foo",
+		"update readme",
+		"\n\n",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, message string) {
+		c, err := ParseMessage(message)
+		if err != nil {
+			return
+		}
+		require.NotEmpty(t, c.Type)
+		require.NotEmpty(t, c.Description)
+		require.Equal(t, strings.TrimSpace(c.Description), c.Description)
+		require.NotContains(t, c.Scope, "(")
+		require.NotContains(t, c.Scope, ")")
+		for _, footer := range c.Footers {
+			require.NotEmpty(t, footer.Token)
+		}
+
+		// the title alone parses the same, and renders back to an equivalent
+		// header.
+		title, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+		header, err := Parse(title, "")
+		require.NoError(t, err)
+		require.Equal(t, c.Type, header.Type)
+		require.Equal(t, c.Scope, header.Scope)
+		require.Equal(t, c.Description, header.Description)
+		require.True(t, c.Breaking || !header.Breaking)
+
+		rendered := header.Type
+		if header.Scope != "" {
+			rendered += "(" + header.Scope + ")"
+		}
+		if header.Breaking {
+			rendered += "!"
+		}
+		again, err := Parse(rendered+": "+header.Description, "")
+		require.NoError(t, err)
+		require.Equal(t, header, again)
+	})
+}
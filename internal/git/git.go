@@ -6,7 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gobwas/glob"
 )
@@ -35,24 +39,91 @@ func Root(ctx context.Context) string {
 	return strings.TrimSpace(out)
 }
 
-func getAllTags(ctx context.Context, args ...string) ([]string, error) {
-	tags, err := run(ctx, append([]string{"-c", "versionsort.suffix=-", "tag", "--sort=-version:refname"}, args...)...)
+func getAllTags(ctx context.Context, tagMode, tagSort string) ([]string, error) {
+	args := []string{"-c", "versionsort.suffix=-", "tag", "--sort=-version:refname"}
+	if tagSort == TagSortDate {
+		args = append(args, "--sort=-creatordate")
+	}
+	if tagMode == TagModeCurrent {
+		args = append(args, "--merged")
+	}
+	out, err := run(ctx, args...)
 	if err != nil {
 		return nil, err
 	}
-	return strings.Split(tags, "\n"), nil
+	tags := strings.Split(out, "\n")
+	if tagSort == TagSortTopo && out != "" {
+		distances, err := topoDistances(ctx)
+		if err != nil {
+			return nil, err
+		}
+		SortTagsByDistance(tags, distances)
+	}
+	return tags, nil
 }
 
-func DescribeTag(ctx context.Context, tagMode string, pattern string) (string, error) {
-	args := []string{}
-	if tagMode == TagModeCurrent {
-		args = []string{"--merged"}
+// topoDistances returns the distance from HEAD of every tag reachable from
+// it.
+func topoDistances(ctx context.Context) (map[string]int, error) {
+	out, err := run(ctx, "for-each-ref", "--format=%(refname:strip=2) %(objectname) %(*objectname)", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+	tags := map[string]string{}
+	for line := range strings.SplitSeq(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		tags[fields[0]] = fields[len(fields)-1]
+	}
+
+	out, err = run(ctx, "rev-list", "--parents", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	graph := map[string][]string{}
+	head := ""
+	for line := range strings.SplitSeq(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if head == "" {
+			head = fields[0]
+		}
+		graph[fields[0]] = fields[1:]
+	}
+	commits, err := distances(head, func(sha string) ([]string, error) {
+		return graph[sha], nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tagDistances(commits, tags), nil
+}
+
+// ListTags returns the tags in the repository, sorted by tagSort. If tagMode
+// is TagModeCurrent, only tags merged into HEAD are returned.
+func ListTags(ctx context.Context, tagMode, tagSort string) ([]string, error) {
+	tags, err := getAllTags(ctx, tagMode, tagSort)
+	if err != nil {
+		return nil, err
 	}
-	tags, err := getAllTags(ctx, args...)
+	return slices.DeleteFunc(tags, func(tag string) bool { return tag == "" }), nil
+}
+
+func DescribeTag(ctx context.Context, tagMode, tagSort string, pattern string) (string, error) {
+	tags, err := getAllTags(ctx, tagMode, tagSort)
 	if err != nil {
 		return "", err
 	}
+	return FindTag(tags, pattern)
+}
 
+// FindTag returns the first of the given tags that matches the given glob
+// pattern, or the first tag if the pattern is empty.
+func FindTag(tags []string, pattern string) (string, error) {
 	if len(tags) == 0 {
 		return "", nil
 	}
@@ -73,24 +144,228 @@ func DescribeTag(ctx context.Context, tagMode string, pattern string) (string, e
 }
 
 func Changelog(ctx context.Context, tag string, dirs []string) ([]Commit, error) {
+	return gitLog(ctx, dirs, ChangelogRange(tag))
+}
+
+// ChangelogRange returns the commit range used by Changelog for the given tag.
+func ChangelogRange(tag string) string {
 	if tag == "" {
-		return gitLog(ctx, dirs, "HEAD")
+		return "HEAD"
+	}
+	return fmt.Sprintf("tags/%s..HEAD", tag)
+}
+
+// HeadSHA returns the SHA of the commit HEAD points to, or an empty string if
+// there are no commits yet.
+func HeadSHA(ctx context.Context) (string, error) {
+	out, exists, err := runCheck(ctx, "rev-parse", "--verify", "--quiet", "HEAD")
+	if err != nil || !exists {
+		return "", err
 	}
-	return gitLog(ctx, dirs, fmt.Sprintf("tags/%s..HEAD", tag))
+	return strings.TrimSpace(out), nil
+}
+
+// HeadTime returns the committer date of the commit HEAD points to.
+func HeadTime(ctx context.Context) (time.Time, error) {
+	out, err := run(ctx, "log", "-1", "--format=%ct", "HEAD")
+	if err != nil {
+		return time.Time{}, err
+	}
+	sec, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(sec, 0).UTC(), nil
+}
+
+// IsDirty returns true if the working tree has uncommitted changes, including
+// untracked files.
+func IsDirty(ctx context.Context) (bool, error) {
+	out, err := run(ctx, "status", "--porcelain")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) != "", nil
+}
+
+// CurrentBranch returns the name of the branch HEAD points to, or an empty
+// string if HEAD is detached.
+func CurrentBranch(ctx context.Context) (string, error) {
+	out, err := run(ctx, "branch", "--show-current")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// CountCommits returns the number of commits since the given tag, or the
+// number of commits in HEAD if tag is empty.
+func CountCommits(ctx context.Context, tag string) (int, error) {
+	out, err := run(ctx, "rev-list", "--count", ChangelogRange(tag))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(out))
+}
+
+// TagExists returns true if the given tag exists in the repository.
+// The tag name is matched exactly, not as a pattern.
+func TagExists(ctx context.Context, tag string) (bool, error) {
+	_, exists, err := runCheck(ctx, "rev-parse", "--verify", "--quiet", "refs/tags/"+tag)
+	return exists, err
+}
+
+// CreateTag creates the given tag pointing to HEAD.
+//
+// The tag is lightweight unless a message is given, in which case it is
+// annotated.
+// If sign is true, the tag is signed using git's own signing configuration.
+func CreateTag(ctx context.Context, tag, message string, sign bool) error {
+	args := []string{"tag"}
+	if sign {
+		if message == "" {
+			message = tag
+		}
+		args = append(args, "--sign", "--message", message)
+	} else if message != "" {
+		args = append(args, "--annotate", "--message", message)
+	}
+	_, err := run(ctx, append(args, "--", tag)...)
+	return err
+}
+
+// DeleteTag deletes the given tag.
+func DeleteTag(ctx context.Context, tag string) error {
+	_, err := run(ctx, "tag", "--delete", "--", tag)
+	return err
+}
+
+// Add stages the given paths, relative to the repository root.
+func Add(ctx context.Context, paths []string) error {
+	args := []string{"add", "--"}
+	for _, p := range paths {
+		args = append(args, ":(top,literal)"+p)
+	}
+	_, err := run(ctx, args...)
+	return err
+}
+
+// CreateCommit commits the staged changes with the given message.
+func CreateCommit(ctx context.Context, message string) error {
+	_, err := run(ctx, "commit", "--quiet", "--message", message)
+	return err
+}
+
+// Reset points HEAD to the given commit, and resets the index to it, keeping
+// the working tree as is.
+func Reset(ctx context.Context, ref string) error {
+	_, err := run(ctx, "reset", "--quiet", "--mixed", ref)
+	return err
+}
+
+// RemoteTagExists returns true if the given remote has the given tag.
+func RemoteTagExists(ctx context.Context, remote, tag string) (bool, error) {
+	out, err := run(ctx, "ls-remote", "--tags", remote, "refs/tags/"+tag)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) != "", nil
+}
+
+// PushTag pushes the given tag to the given remote.
+func PushTag(ctx context.Context, remote, tag string) error {
+	_, err := run(ctx, "push", remote, "refs/tags/"+tag)
+	return err
+}
+
+// AddWorktree checks out the given ref, detached, in a new worktree at the
+// given directory.
+func AddWorktree(ctx context.Context, dir, ref string) error {
+	_, err := run(ctx, "worktree", "add", "--detach", "--force", dir, ref)
+	return err
+}
+
+// RemoveWorktree removes the worktree at the given directory.
+func RemoveWorktree(ctx context.Context, dir string) error {
+	_, err := run(ctx, "worktree", "remove", "--force", dir)
+	return err
+}
+
+// HooksDir returns the absolute path of the hooks directory, respecting
+// core.hooksPath.
+func HooksDir(ctx context.Context) (string, error) {
+	out, err := run(ctx, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(strings.TrimSpace(out))
+}
+
+// IsShallow returns true if the repository is a shallow clone.
+func IsShallow(ctx context.Context) (bool, error) {
+	out, err := run(ctx, "rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) == "true", nil
+}
+
+// TagReachable returns true if the given tag points to a commit that is
+// reachable from HEAD, which in a shallow clone also means the history between
+// them is available.
+func TagReachable(ctx context.Context, tag string) (bool, error) {
+	_, reachable, err := runCheck(ctx, "merge-base", "--is-ancestor", "refs/tags/"+tag, "HEAD")
+	return reachable, err
+}
+
+// Deepen fetches the given number of commits more of history, and the tags,
+// from the default remote.
+func Deepen(ctx context.Context, depth int) error {
+	_, err := run(ctx, "fetch", "--tags", "--deepen="+strconv.Itoa(depth))
+	return err
 }
 
 func run(ctx context.Context, args ...string) (string, error) {
+	out, err := runCommand(ctx, args...)
+	if err != nil {
+		return "", errors.New(out)
+	}
+	return out, nil
+}
+
+// runCheck runs git commands that exit with 1 to answer no, e.g.
+// merge-base --is-ancestor, and returns their output and whether they exited
+// with 0.
+func runCheck(ctx context.Context, args ...string) (string, bool, error) {
+	out, err := runCommand(ctx, args...)
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 1 {
+		return out, false, nil
+	}
+	if err != nil {
+		return "", false, errors.New(out)
+	}
+	return out, true, nil
+}
+
+type dirKey struct{}
+
+// inDir returns a context in which git commands run in the given directory,
+// instead of the current one.
+func inDir(ctx context.Context, dir string) context.Context {
+	return context.WithValue(ctx, dirKey{}, dir)
+}
+
+func runCommand(ctx context.Context, args ...string) (string, error) {
 	extraArgs := []string{
 		"-c", "log.showSignature=false",
 	}
 	args = append(extraArgs, args...)
 	/* #nosec */
 	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir, _ = ctx.Value(dirKey{}).(string)
 	bts, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", errors.New(string(bts))
-	}
-	return string(bts), nil
+	return string(bts), err
 }
 
 func gitLog(ctx context.Context, dirs []string, refs ...string) ([]Commit, error) {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path"
+	"strings"
 	"testing"
 	"time"
 
@@ -33,26 +34,150 @@ func TestDescribeTag(t *testing.T) {
 	}
 	t.Run(TagModeCurrent, func(t *testing.T) {
 		setup(t)
-		tag, err := DescribeTag(t.Context(), TagModeCurrent, "")
+		tag, err := DescribeTag(t.Context(), TagModeCurrent, TagSortSemver, "")
 		require.NoError(t, err)
 		require.Equal(t, "v1.2.4", tag)
 	})
 
 	t.Run(TagModeAll, func(t *testing.T) {
 		setup(t)
-		tag, err := DescribeTag(t.Context(), TagModeAll, "")
+		tag, err := DescribeTag(t.Context(), TagModeAll, TagSortSemver, "")
 		require.NoError(t, err)
 		require.Equal(t, "v1.2.5", tag)
 	})
 
 	t.Run("pattern", func(t *testing.T) {
 		setup(t)
-		tag, err := DescribeTag(t.Context(), TagModeCurrent, "pattern-*")
+		tag, err := DescribeTag(t.Context(), TagModeCurrent, TagSortSemver, "pattern-*")
 		require.NoError(t, err)
 		require.Equal(t, "pattern-1.2.3", tag)
 	})
 }
 
+func TestListTags(t *testing.T) {
+	backends(t, func(t *testing.T, open func(testing.TB) Repository) {
+		tempdir(t)
+		gitInit(t)
+		gitCommit(t, "chore: foobar")
+		tags := []string{"v1.0.0", "v1.0.0-rc2", "v1.0.0-rc10", "v1.10.0", "v1.9.1", "v2.0.0-beta.1", "api/v0.1.0", "nope"}
+		for _, tag := range tags {
+			gitTag(t, tag)
+		}
+		createBranch(t, "other")
+		gitCommit(t, "chore: other")
+		gitTag(t, "v3.0.0")
+		switchToBranch(t, "-")
+		repo := open(t)
+
+		all, err := repo.ListTags(t.Context(), TagModeAll, TagSortSemver)
+		require.NoError(t, err)
+		require.Equal(t, []string{"v3.0.0", "v2.0.0-beta.1", "v1.10.0", "v1.9.1", "v1.0.0", "v1.0.0-rc10", "v1.0.0-rc2", "nope", "api/v0.1.0"}, all)
+
+		current, err := repo.ListTags(t.Context(), TagModeCurrent, TagSortSemver)
+		require.NoError(t, err)
+		require.Equal(t, all[1:], current)
+
+		t.Run("same order as git", func(t *testing.T) {
+			sorted := append([]string{"v3.0.0"}, tags...)
+			SortTags(sorted)
+			require.Equal(t, all, sorted)
+		})
+	})
+}
+
+func TestCurrentBranch(t *testing.T) {
+	backends(t, func(t *testing.T, open func(testing.TB) Repository) {
+		tempdir(t)
+		gitInit(t)
+		gitCommit(t, "chore: foobar")
+		createBranch(t, "release/1.x")
+		branch, err := open(t).CurrentBranch(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, "release/1.x", branch)
+
+		_, err = fakeGitRun(t.Context(), "switch", "--detach")
+		require.NoError(t, err)
+		branch, err = open(t).CurrentBranch(t.Context())
+		require.NoError(t, err)
+		require.Empty(t, branch)
+	})
+}
+
+func TestHeadTime(t *testing.T) {
+	backends(t, func(t *testing.T, open func(testing.TB) Repository) {
+		tempdir(t)
+		gitInit(t)
+		t.Setenv("GIT_COMMITTER_DATE", "1700000000 +0300")
+		gitCommit(t, "chore: foobar")
+		tm, err := open(t).HeadTime(t.Context())
+		require.NoError(t, err)
+		require.Equal(t, time.Unix(1700000000, 0).UTC(), tm)
+	})
+}
+
+func TestIsDirty(t *testing.T) {
+	tempdir(t)
+	gitInit(t)
+	gitCommit(t, "chore: foobar")
+	dirty, err := IsDirty(t.Context())
+	require.NoError(t, err)
+	require.False(t, dirty)
+
+	require.NoError(t, os.WriteFile("file", []byte("foo"), 0o644))
+	dirty, err = IsDirty(t.Context())
+	require.NoError(t, err)
+	require.True(t, dirty)
+}
+
+func TestTagSort(t *testing.T) {
+	backends(t, func(t *testing.T, open func(testing.TB) Repository) {
+		at := func(date string) {
+			t.Setenv("GIT_COMMITTER_DATE", date+" +0000")
+		}
+		tempdir(t)
+		gitInit(t)
+		at("1700001000")
+		gitCommit(t, "chore: v1")
+		gitTag(t, "v1.0.0")
+		at("1700005000")
+		_, err := fakeGitRun(t.Context(), "tag", "-a", "-m", "late", "v1.4.9")
+		require.NoError(t, err)
+		at("1700002000")
+		gitCommit(t, "feat!: v2")
+		gitTag(t, "v2.0.0")
+		_, err = fakeGitRun(t.Context(), "switch", "-c", "hotfix", "v1.0.0")
+		require.NoError(t, err)
+		at("1700003000")
+		gitCommit(t, "fix: hotfix")
+		gitTag(t, "v1.0.1")
+		switchToBranch(t, "-")
+		repo := open(t)
+
+		for sort, expected := range map[string][]string{
+			TagSortSemver: {"v2.0.0", "v1.4.9", "v1.0.1", "v1.0.0"},
+			TagSortDate:   {"v1.4.9", "v1.0.1", "v2.0.0", "v1.0.0"},
+			TagSortTopo:   {"v2.0.0", "v1.4.9", "v1.0.0", "v1.0.1"},
+		} {
+			t.Run(sort, func(t *testing.T) {
+				tags, err := repo.ListTags(t.Context(), TagModeAll, sort)
+				require.NoError(t, err)
+				require.Equal(t, expected, tags)
+			})
+		}
+
+		t.Run("nearest", func(t *testing.T) {
+			switchToBranch(t, "hotfix")
+			repo := open(t)
+			tag, err := repo.DescribeTag(t.Context(), TagModeCurrent, TagSortTopo, "")
+			require.NoError(t, err)
+			require.Equal(t, "v1.0.1", tag)
+			tag, err = repo.DescribeTag(t.Context(), TagModeCurrent, TagSortSemver, "")
+			require.NoError(t, err)
+			require.Equal(t, "v1.4.9", tag)
+		})
+	})
+}
+
 func TestChangelog(t *testing.T) {
 	tempdir(t)
 	gitInit(t)
@@ -114,6 +239,443 @@ func TestChangelogWithDirectory(t *testing.T) {
 	requireLogNotContains(t, log, "feat: foobar")
 }
 
+func TestCountCommits(t *testing.T) {
+	backends(t, func(t *testing.T, open func(testing.TB) Repository) {
+		tempdir(t)
+		gitInit(t)
+		sha, err := open(t).HeadSHA(t.Context())
+		require.NoError(t, err)
+		require.Empty(t, sha)
+
+		gitCommit(t, "chore: foobar")
+		gitTag(t, "v1.0.0")
+		gitCommit(t, "fix: foo")
+		gitCommit(t, "feat: bar")
+		repo := open(t)
+
+		count, err := repo.CountCommits(t.Context(), "v1.0.0")
+		require.NoError(t, err)
+		require.Equal(t, 2, count)
+
+		count, err = repo.CountCommits(t.Context(), "")
+		require.NoError(t, err)
+		require.Equal(t, 3, count)
+
+		sha, err = repo.HeadSHA(t.Context())
+		require.NoError(t, err)
+		out, err := fakeGitRun(t.Context(), "log", "-1", "--format=%H")
+		require.NoError(t, err)
+		require.Equal(t, strings.TrimSpace(out), sha)
+	})
+}
+
+func TestCreateTag(t *testing.T) {
+	backends(t, func(t *testing.T, open func(testing.TB) Repository) {
+		tempdir(t)
+		gitInit(t)
+		gitCommit(t, "chore: foobar")
+		repo := open(t)
+
+		exists, err := repo.TagExists(t.Context(), "v1.0.0")
+		require.NoError(t, err)
+		require.False(t, exists)
+
+		t.Run("lightweight", func(t *testing.T) {
+			require.NoError(t, repo.CreateTag(t.Context(), "v1.0.0", "", false))
+			exists, err := repo.TagExists(t.Context(), "v1.0.0")
+			require.NoError(t, err)
+			require.True(t, exists)
+			requireTagType(t, "v1.0.0", "commit")
+		})
+
+		t.Run("annotated", func(t *testing.T) {
+			require.NoError(t, repo.CreateTag(t.Context(), "v1.1.0", "release v1.1.0", false))
+			requireTagType(t, "v1.1.0", "tag")
+			out, err := fakeGitRun(t.Context(), "tag", "--list", "--format=%(contents:subject)", "v1.1.0")
+			require.NoError(t, err)
+			require.Equal(t, "release v1.1.0", strings.TrimSpace(out))
+		})
+
+		t.Run("already exists", func(t *testing.T) {
+			require.Error(t, repo.CreateTag(t.Context(), "v1.0.0", "", false))
+		})
+
+		t.Run("exact name", func(t *testing.T) {
+			for _, tag := range []string{"v1.*", "v[1].0.0", "v1.0", "1.0.0"} {
+				exists, err := repo.TagExists(t.Context(), tag)
+				require.NoError(t, err)
+				require.False(t, exists, tag)
+			}
+		})
+	})
+}
+
+func TestReleaseCommit(t *testing.T) {
+	tempdir(t)
+	gitInit(t)
+	gitCommit(t, "chore: foobar")
+	head, err := HeadSHA(t.Context())
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir("sub", 0o755))
+	require.NoError(t, os.WriteFile("VERSION", []byte("1.0.0"), 0o644))
+	t.Chdir("sub")
+
+	require.NoError(t, Add(t.Context(), []string{"VERSION"}))
+	require.NoError(t, CreateCommit(t.Context(), "chore(release): v1.0.0"))
+	require.NoError(t, CreateTag(t.Context(), "v1.0.0", "", false))
+	count, err := CountCommits(t.Context(), "")
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	require.NoError(t, DeleteTag(t.Context(), "v1.0.0"))
+	exists, err := TagExists(t.Context(), "v1.0.0")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, Reset(t.Context(), head))
+	sha, err := HeadSHA(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, head, sha)
+	out, err := fakeGitRun(t.Context(), "status", "--porcelain")
+	require.NoError(t, err)
+	require.Equal(t, "?? VERSION", strings.TrimSpace(out))
+}
+
+func TestHooksDir(t *testing.T) {
+	dir := tempdir(t)
+	gitInit(t)
+	hooks, err := HooksDir(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, path.Join(dir, ".git", "hooks"), hooks)
+
+	_, err = fakeGitRun(t.Context(), "config", "core.hooksPath", "/tmp/svu-hooks")
+	require.NoError(t, err)
+	hooks, err = HooksDir(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/svu-hooks", hooks)
+}
+
+func TestPushTag(t *testing.T) {
+	remote := t.TempDir()
+	_, err := fakeGitRun(t.Context(), "init", "--bare", remote)
+	require.NoError(t, err)
+
+	tempdir(t)
+	gitInit(t)
+	gitCommit(t, "chore: foobar")
+	_, err = fakeGitRun(t.Context(), "remote", "add", "origin", remote)
+	require.NoError(t, err)
+	gitTag(t, "v1.0.0")
+
+	exists, err := RemoteTagExists(t.Context(), "origin", "v1.0.0")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, PushTag(t.Context(), "origin", "v1.0.0"))
+
+	exists, err = RemoteTagExists(t.Context(), "origin", "v1.0.0")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	exists, err = RemoteTagExists(t.Context(), "origin", "v1.0")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	t.Run("invalid remote", func(t *testing.T) {
+		_, err := RemoteTagExists(t.Context(), "nope", "v1.0.0")
+		require.Error(t, err)
+		require.Error(t, PushTag(t.Context(), "nope", "v1.0.0"))
+	})
+}
+
+func TestWorktree(t *testing.T) {
+	tempdir(t)
+	gitInit(t)
+	require.NoError(t, os.WriteFile("file", []byte("v1"), 0o644))
+	gitAdd(t, "file")
+	gitCommit(t, "chore: v1")
+	gitTag(t, "v1.0.0")
+	require.NoError(t, os.WriteFile("file", []byte("v2"), 0o644))
+	gitCommit(t, "chore: v2")
+
+	dir := path.Join(t.TempDir(), "wt")
+	require.NoError(t, AddWorktree(t.Context(), dir, "v1.0.0"))
+	bts, err := os.ReadFile(path.Join(dir, "file"))
+	require.NoError(t, err)
+	require.Equal(t, "v1", string(bts))
+
+	require.NoError(t, RemoveWorktree(t.Context(), dir))
+	require.NoDirExists(t, dir)
+
+	require.Error(t, AddWorktree(t.Context(), dir, "nope"))
+}
+
+func TestShallow(t *testing.T) {
+	origin := tempdir(t)
+	gitInit(t)
+	gitCommit(t, "chore: v1")
+	gitTag(t, "v1.0.0")
+	gitCommit(t, "fix: foo")
+	gitCommit(t, "feat: bar")
+
+	clone := t.TempDir()
+	_, err := fakeGitRun(t.Context(), "clone", "--depth=1", "file://"+origin, clone)
+	require.NoError(t, err)
+	t.Chdir(clone)
+
+	backends(t, func(t *testing.T, open func(testing.TB) Repository) {
+		shallow, err := open(t).IsShallow(t.Context())
+		require.NoError(t, err)
+		require.True(t, shallow)
+	})
+
+	tag, err := DescribeTag(t.Context(), TagModeCurrent, TagSortSemver, "")
+	require.NoError(t, err)
+	require.Empty(t, tag)
+
+	require.NoError(t, Deepen(t.Context(), 1))
+	shallow, err := IsShallow(t.Context())
+	require.NoError(t, err)
+	require.True(t, shallow)
+
+	require.NoError(t, Deepen(t.Context(), 1))
+	tag, err = DescribeTag(t.Context(), TagModeCurrent, TagSortSemver, "")
+	require.NoError(t, err)
+	require.Equal(t, "v1.0.0", tag)
+
+	backends(t, func(t *testing.T, open func(testing.TB) Repository) {
+		reachable, err := open(t).TagReachable(t.Context(), "v1.0.0")
+		require.NoError(t, err)
+		require.True(t, reachable)
+	})
+
+	t.Run("not shallow", func(t *testing.T) {
+		t.Chdir(origin)
+		shallow, err := IsShallow(t.Context())
+		require.NoError(t, err)
+		require.False(t, shallow)
+	})
+}
+
+func TestTagReachable(t *testing.T) {
+	backends(t, func(t *testing.T, open func(testing.TB) Repository) {
+		tempdir(t)
+		gitInit(t)
+		gitCommit(t, "chore: foobar")
+		gitTag(t, "v1.0.0")
+		createBranch(t, "feature")
+		gitCommit(t, "feat: foo")
+		gitTag(t, "v1.1.0")
+		switchToBranch(t, "-")
+		repo := open(t)
+
+		reachable, err := repo.TagReachable(t.Context(), "v1.0.0")
+		require.NoError(t, err)
+		require.True(t, reachable)
+
+		reachable, err = repo.TagReachable(t.Context(), "v1.1.0")
+		require.NoError(t, err)
+		require.False(t, reachable)
+
+		_, err = repo.TagReachable(t.Context(), "v2.0.0")
+		require.Error(t, err)
+	})
+}
+
+func TestNativeMatchesExec(t *testing.T) {
+	dir := tempdir(t)
+	gitInit(t)
+	write := func(tb testing.TB, name, content string) {
+		tb.Helper()
+		p := path.Join(dir, name)
+		require.NoError(tb, os.MkdirAll(path.Dir(p), 0o755))
+		require.NoError(tb, os.WriteFile(p, []byte(content), 0o644))
+		gitAdd(tb, p)
+	}
+
+	write(t, "README.md", "hello")
+	gitCommit(t, "chore: init")
+	gitTag(t, "v0.1.0")
+	write(t, "lib/lib.go", "package lib")
+	gitCommit(t, "feat: lib\n\nsome body\n\nBREAKING CHANGE: yes")
+	_, err := fakeGitRun(t.Context(), "tag", "-a", "-m", "annotated", "v1.0.0")
+	require.NoError(t, err)
+	createBranch(t, "feature")
+	write(t, "app/main.go", "package main")
+	gitCommit(t, "feat: app")
+	write(t, "lib/nested/go.mod", "module nested")
+	gitCommit(t, "fix: nested")
+	switchToBranch(t, "-")
+	write(t, "lib/lib.go", "package lib // changed")
+	gitCommit(t, "fix: lib")
+	_, err = fakeGitRun(t.Context(), "merge", "--no-ff", "-m", "Merge branch 'feature'", "feature")
+	require.NoError(t, err)
+	gitTag(t, "lib/v1.1.0")
+	gitTag(t, "v1.1.0")
+	gitTag(t, "v1.1.0-rc.1") // multiple tags in a single commit
+	createBranch(t, "other")
+	gitCommit(t, "chore: other")
+	gitTag(t, "v2.0.0")
+	switchToBranch(t, "-")
+	write(t, "app/main.go", "package main // changed")
+	gitCommit(t, "fix: app")
+	require.NoError(t, os.Mkdir(path.Join(dir, "sub"), 0o755))
+
+	compare := func(t *testing.T) {
+		t.Helper()
+		native, err := NewNative(".")
+		require.NoError(t, err)
+		exec := Exec{}
+		ctx := t.Context()
+
+		for _, mode := range []string{TagModeAll, TagModeCurrent} {
+			for _, sort := range []string{TagSortSemver, TagSortDate, TagSortTopo} {
+				expected, err := exec.ListTags(ctx, mode, sort)
+				require.NoError(t, err)
+				actual, err := native.ListTags(ctx, mode, sort)
+				require.NoError(t, err)
+				require.Equal(t, expected, actual, mode+" "+sort)
+
+				for _, pattern := range []string{"", "v*", "lib/*"} {
+					expected, err := exec.DescribeTag(ctx, mode, sort, pattern)
+					require.NoError(t, err)
+					actual, err := native.DescribeTag(ctx, mode, sort, pattern)
+					require.NoError(t, err)
+					require.Equal(t, expected, actual, mode+" "+sort+" "+pattern)
+				}
+			}
+		}
+
+		for _, tag := range []string{"", "v0.1.0", "v1.0.0", "lib/v1.1.0", "v2.0.0"} {
+			expected, err := exec.CountCommits(ctx, tag)
+			require.NoError(t, err)
+			actual, err := native.CountCommits(ctx, tag)
+			require.NoError(t, err)
+			require.Equal(t, expected, actual, tag)
+
+			for _, dirs := range [][]string{
+				nil,
+				{"lib"},
+				{"app", "README.md"},
+				{":(top)lib", ":(top,exclude)lib/nested"},
+				{":!lib"},
+				{"*.go"},
+				{path.Join(dir, "app")},
+				{"nope"},
+			} {
+				expected, err := exec.Changelog(ctx, tag, dirs)
+				require.NoError(t, err)
+				actual, err := native.Changelog(ctx, tag, dirs)
+				require.NoError(t, err)
+				require.Equal(t, expected, actual, "%s %v", tag, dirs)
+			}
+		}
+
+		_, err = native.Changelog(ctx, "v9.9.9", nil)
+		require.Error(t, err)
+
+		expected, err := exec.HeadSHA(ctx)
+		require.NoError(t, err)
+		actual, err := native.HeadSHA(ctx)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+		require.Equal(t, exec.Root(ctx), native.Root(ctx))
+	}
+
+	t.Run("loose", compare)
+
+	t.Run("subdirectory", func(t *testing.T) {
+		t.Chdir(path.Join(dir, "lib"))
+		native, err := NewNative(".")
+		require.NoError(t, err)
+		for _, dirs := range [][]string{{"."}, {"nested"}, {":(top)app"}, {"../app"}} {
+			expected, err := Exec{}.Changelog(t.Context(), "v1.0.0", dirs)
+			require.NoError(t, err)
+			actual, err := native.Changelog(t.Context(), "v1.0.0", dirs)
+			require.NoError(t, err)
+			require.Equal(t, expected, actual, dirs)
+		}
+	})
+
+	t.Run("packed", func(t *testing.T) {
+		_, err := fakeGitRun(t.Context(), "gc", "--aggressive", "--prune=now")
+		require.NoError(t, err)
+		out, err := fakeGitRun(t.Context(), "count-objects", "-v")
+		require.NoError(t, err)
+		require.Contains(t, out, "count: 0\n")
+		require.FileExists(t, path.Join(dir, ".git", "packed-refs"))
+		compare(t)
+	})
+
+	t.Run("create tag", func(t *testing.T) {
+		native, err := NewNative(".")
+		require.NoError(t, err)
+		require.NoError(t, native.CreateTag(t.Context(), "v3.0.0", "", false))
+		require.NoError(t, native.CreateTag(t.Context(), "v3.0.1", "native\n\nrelease", false))
+		require.Error(t, native.CreateTag(t.Context(), "v3.0.1", "", false))
+
+		lock := path.Join(dir, ".git", "refs", "tags", "v3.0.2.lock")
+		require.NoError(t, os.WriteFile(lock, nil, 0o644))
+		require.ErrorIs(t, native.CreateTag(t.Context(), "v3.0.2", "", false), os.ErrExist)
+		require.FileExists(t, lock)
+		require.NoFileExists(t, path.Join(dir, ".git", "refs", "tags", "v3.0.2"))
+		require.NoError(t, os.Remove(lock))
+
+		requireTagType(t, "v3.0.0", "commit")
+		requireTagType(t, "v3.0.1", "tag")
+		out, err := fakeGitRun(t.Context(), "tag", "--list", "--format=%(contents)", "v3.0.1")
+		require.NoError(t, err)
+		require.Equal(t, "native\n\nrelease", strings.TrimSpace(out))
+		_, err = fakeGitRun(t.Context(), "fsck", "--strict")
+		require.NoError(t, err)
+		compare(t)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		native, err := NewNative(".")
+		require.NoError(t, err)
+		t.Chdir(t.TempDir())
+		require.NoError(t, os.WriteFile(path.Join(dir, "native"), []byte("foo"), 0o644))
+		dirty, err := native.IsDirty(t.Context())
+		require.NoError(t, err)
+		require.True(t, dirty)
+		require.NoError(t, native.Add(t.Context(), []string{"native"}))
+		require.NoError(t, native.CreateCommit(t.Context(), "chore: native"))
+		require.NoError(t, native.DeleteTag(t.Context(), "v3.0.0"))
+		t.Chdir(dir)
+		compare(t)
+
+		t.Setenv("PATH", t.TempDir())
+		require.ErrorIs(t, native.Add(t.Context(), []string{"native"}), errNativeUnsupported)
+		require.ErrorIs(t, native.CreateTag(t.Context(), "v3.0.2", "", true), errNativeUnsupported)
+		require.ErrorIs(t, native.Deepen(t.Context(), 10), errNativeUnsupported)
+	})
+}
+
+// backends runs the given test with every Repository implementation, opened
+// in the current directory.
+func backends(t *testing.T, test func(t *testing.T, open func(testing.TB) Repository)) {
+	t.Helper()
+	t.Run(BackendExec, func(t *testing.T) {
+		test(t, func(testing.TB) Repository { return Exec{} })
+	})
+	t.Run(BackendNative, func(t *testing.T) {
+		test(t, func(tb testing.TB) Repository {
+			tb.Helper()
+			repo, err := NewNative(".")
+			require.NoError(tb, err)
+			return repo
+		})
+	})
+}
+
+func requireTagType(tb testing.TB, tag, kind string) {
+	tb.Helper()
+	out, err := fakeGitRun(tb.Context(), "cat-file", "-t", tag)
+	require.NoError(tb, err)
+	require.Equal(tb, kind, strings.TrimSpace(out))
+}
+
 func switchToBranch(tb testing.TB, branch string) {
 	tb.Helper()
 	_, err := fakeGitRun(tb.Context(), "switch", branch)
@@ -148,6 +710,16 @@ func gitInit(tb testing.TB) {
 	tb.Helper()
 	_, err := fakeGitRun(tb.Context(), "init")
 	require.NoError(tb, err)
+	// svu itself runs git without the fake identity, so set it in the repo.
+	for _, kv := range [][2]string{
+		{"user.name", "svu"},
+		{"user.email", "svu@example.com"},
+		{"commit.gpgSign", "false"},
+		{"tag.gpgSign", "false"},
+	} {
+		_, err := fakeGitRun(tb.Context(), "config", kv[0], kv[1])
+		require.NoError(tb, err)
+	}
 }
 
 func tempdir(tb testing.TB) string {
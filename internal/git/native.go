@@ -0,0 +1,1355 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"container/heap"
+	"context"
+	"crypto/sha1" //nolint:gosec
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Backends that can be used to read the repository.
+const (
+	BackendExec   = "exec"
+	BackendNative = "native"
+)
+
+// Native is a Repository that reads the git object database directly, without
+// the git binary.
+//
+// It supports loose objects, packfiles, packed refs and shallow clones. The
+// operations it cannot do itself, e.g. committing, pushing, signing tags and
+// fetching, fall back to the git binary when it is installed.
+type Native struct {
+	gitDir    string
+	commonDir string
+	root      string
+	cwd       string
+	objects   objectStore
+	shallow   map[string]bool
+	commits   map[string]*nativeCommit
+}
+
+var _ Repository = &Native{}
+
+var (
+	errNativeUnsupported = errors.New("not supported by the native git backend")
+	errUnknownRevision   = errors.New("unknown revision")
+)
+
+type nativeCommit struct {
+	sha     string
+	tree    string
+	parents []string
+	time    int64
+	message string
+}
+
+// NewNative opens the repository containing the given directory.
+func NewNative(dir string) (*Native, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+
+	n := &Native{
+		cwd:     dir,
+		commits: map[string]*nativeCommit{},
+	}
+	for root := dir; ; root = filepath.Dir(root) {
+		dotgit := filepath.Join(root, ".git")
+		info, err := os.Stat(dotgit)
+		if err == nil && info.IsDir() {
+			n.root, n.gitDir = root, dotgit
+			break
+		}
+		if err == nil {
+			bts, err := os.ReadFile(dotgit)
+			if err != nil {
+				return nil, err
+			}
+			gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(bts)), "gitdir:")
+			if !ok {
+				return nil, fmt.Errorf("invalid .git file: %s", dotgit)
+			}
+			gitDir = strings.TrimSpace(gitDir)
+			if !filepath.IsAbs(gitDir) {
+				gitDir = filepath.Join(root, gitDir)
+			}
+			n.root, n.gitDir = root, gitDir
+			break
+		}
+		if filepath.Dir(root) == root {
+			return nil, errors.New("not a git repository (or any of the parent directories): .git")
+		}
+	}
+
+	n.commonDir = n.gitDir
+	if bts, err := os.ReadFile(filepath.Join(n.gitDir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(bts))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(n.gitDir, common)
+		}
+		n.commonDir = filepath.Clean(common)
+	}
+
+	if err := n.load(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// load opens the object database and reads the shallow commits, again after
+// a fetch added packfiles.
+func (n *Native) load() error {
+	n.objects = objectStore{}
+	if err := n.objects.open(filepath.Join(n.commonDir, "objects")); err != nil {
+		return err
+	}
+
+	n.shallow = map[string]bool{}
+	if bts, err := os.ReadFile(filepath.Join(n.commonDir, "shallow")); err == nil {
+		for sha := range strings.FieldsSeq(string(bts)) {
+			n.shallow[sha] = true
+		}
+	}
+	return nil
+}
+
+// fallback returns a context to run an operation the native backend does not
+// support with the git binary, in the directory the repository was opened
+// in, or an error if git is not installed.
+func (n *Native) fallback(ctx context.Context, what string) (context.Context, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return ctx, fmt.Errorf("%s is %w, and git is not installed", what, errNativeUnsupported)
+	}
+	return inDir(ctx, n.cwd), nil
+}
+
+// Root implements Repository.
+func (n *Native) Root(context.Context) string {
+	return n.root
+}
+
+// ListTags implements Repository.
+func (n *Native) ListTags(_ context.Context, tagMode, tagSort string) ([]string, error) {
+	refs, err := n.tagRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	var reachable map[string]int
+	if tagMode == TagModeCurrent || tagSort == TagSortTopo && len(refs) > 0 {
+		head, err := n.resolve("HEAD")
+		if err != nil {
+			return nil, err
+		}
+		reachable, err = distances(head, func(sha string) ([]string, error) {
+			c, err := n.commit(sha)
+			if err != nil {
+				return nil, err
+			}
+			return n.parents(c), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tags := make([]string, 0, len(refs))
+	commits := map[string]string{}
+	for name, sha := range refs {
+		commit, err := n.peel(sha)
+		if err != nil {
+			if tagMode == TagModeCurrent {
+				continue
+			}
+			commit = ""
+		}
+		if _, ok := reachable[commit]; tagMode == TagModeCurrent && !ok {
+			continue
+		}
+		commits[name] = commit
+		tags = append(tags, name)
+	}
+
+	switch tagSort {
+	case TagSortDate:
+		dates := map[string]int64{}
+		for _, name := range tags {
+			dates[name] = n.tagDate(refs[name])
+		}
+		SortTagsByDate(tags, dates)
+	case TagSortTopo:
+		SortTagsByDistance(tags, tagDistances(reachable, commits))
+	default:
+		SortTags(tags)
+	}
+	return tags, nil
+}
+
+// DescribeTag implements Repository.
+func (n *Native) DescribeTag(ctx context.Context, tagMode, tagSort string, pattern string) (string, error) {
+	tags, err := n.ListTags(ctx, tagMode, tagSort)
+	if err != nil {
+		return "", err
+	}
+	return FindTag(tags, pattern)
+}
+
+// tagDate returns the creation date of the given tag object as a unix
+// timestamp: the tagger date of annotated tags, or the committer date of the
+// commit lightweight tags point to. It is zero if it cannot be read.
+func (n *Native) tagDate(sha string) int64 {
+	typ, data, err := n.objects.read(sha)
+	if err != nil {
+		return 0
+	}
+	if typ != "tag" {
+		c, err := n.commit(sha)
+		if err != nil {
+			return 0
+		}
+		return c.time
+	}
+	headers, _, _ := bytes.Cut(data, []byte("\n\n"))
+	for line := range strings.SplitSeq(string(headers), "\n") {
+		if value, ok := strings.CutPrefix(line, "tagger "); ok {
+			fields := strings.Fields(value)
+			if len(fields) >= 2 {
+				date, _ := strconv.ParseInt(fields[len(fields)-2], 10, 64)
+				return date
+			}
+		}
+	}
+	return 0
+}
+
+// Changelog implements Repository.
+//
+// Commits are returned in commit date order, and when filtering by
+// directories, merges are simplified the same way git log does by default.
+func (n *Native) Changelog(_ context.Context, tag string, dirs []string) ([]Commit, error) {
+	rel, err := filepath.Rel(n.root, n.cwd)
+	if err != nil {
+		return nil, err
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		rel = ""
+	}
+	specs := parsePathspecs(dirs, n.root, rel)
+
+	var result []Commit
+	err = n.walk(tag, specs, func(c *nativeCommit) {
+		message := strings.TrimRight(c.message, " \t\n")
+		title, body, _ := strings.Cut(message, "\n")
+		result = append(result, Commit{SHA: c.sha, Title: title, Body: body})
+	})
+	return result, err
+}
+
+// HeadSHA implements Repository.
+func (n *Native) HeadSHA(context.Context) (string, error) {
+	sha, err := n.resolve("HEAD")
+	if errors.Is(err, errUnknownRevision) {
+		return "", nil
+	}
+	return sha, err
+}
+
+// HeadTime implements Repository.
+func (n *Native) HeadTime(context.Context) (time.Time, error) {
+	head, err := n.resolve("HEAD")
+	if err != nil {
+		return time.Time{}, err
+	}
+	c, err := n.commit(head)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(c.time, 0).UTC(), nil
+}
+
+// IsDirty implements Repository, using the git binary.
+func (n *Native) IsDirty(ctx context.Context) (bool, error) {
+	ctx, err := n.fallback(ctx, "reading the working tree status")
+	if err != nil {
+		return false, err
+	}
+	return IsDirty(ctx)
+}
+
+// CurrentBranch implements Repository.
+func (n *Native) CurrentBranch(context.Context) (string, error) {
+	bts, err := os.ReadFile(filepath.Join(n.gitDir, "HEAD"))
+	if err != nil {
+		return "", err
+	}
+	ref, _ := strings.CutPrefix(strings.TrimSpace(string(bts)), "ref: ")
+	branch, _ := strings.CutPrefix(ref, "refs/heads/")
+	if branch == ref {
+		return "", nil
+	}
+	return branch, nil
+}
+
+// CountCommits implements Repository.
+func (n *Native) CountCommits(_ context.Context, tag string) (int, error) {
+	count := 0
+	err := n.walk(tag, pathspecs{}, func(*nativeCommit) { count++ })
+	return count, err
+}
+
+// TagExists implements Repository.
+func (n *Native) TagExists(_ context.Context, tag string) (bool, error) {
+	refs, err := n.tagRefs()
+	if err != nil {
+		return false, err
+	}
+	_, ok := refs[tag]
+	return ok, nil
+}
+
+// CreateTag implements Repository.
+//
+// Signed tags are created with the git binary.
+func (n *Native) CreateTag(ctx context.Context, tag, message string, sign bool) error {
+	if sign {
+		ctx, err := n.fallback(ctx, "signing tags")
+		if err != nil {
+			return err
+		}
+		return CreateTag(ctx, tag, message, sign)
+	}
+	exists, err := n.TagExists(ctx, tag)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("tag '%s' already exists", tag)
+	}
+	head, err := n.resolve("HEAD")
+	if err != nil {
+		return err
+	}
+
+	target := head
+	if message != "" {
+		tagger, err := n.identity()
+		if err != nil {
+			return err
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "object %s\ntype commit\ntag %s\ntagger %s %d %s\n\n", head, tag, tagger, time.Now().Unix(), time.Now().Format("-0700"))
+		sb.WriteString(strings.TrimRight(message, " \t\n") + "\n")
+		target, err = n.objects.writeLoose("tag", []byte(sb.String()))
+		if err != nil {
+			return fmt.Errorf("failed to write tag object: %w", err)
+		}
+	}
+
+	ref := filepath.Join(n.commonDir, "refs", "tags", filepath.FromSlash(tag))
+	return writeRef(ref, target)
+}
+
+// DeleteTag implements Repository, using the git binary.
+func (n *Native) DeleteTag(ctx context.Context, tag string) error {
+	ctx, err := n.fallback(ctx, "deleting tags")
+	if err != nil {
+		return err
+	}
+	return DeleteTag(ctx, tag)
+}
+
+// Add implements Repository, using the git binary.
+func (n *Native) Add(ctx context.Context, paths []string) error {
+	ctx, err := n.fallback(ctx, "staging files")
+	if err != nil {
+		return err
+	}
+	return Add(ctx, paths)
+}
+
+// CreateCommit implements Repository, using the git binary.
+func (n *Native) CreateCommit(ctx context.Context, message string) error {
+	ctx, err := n.fallback(ctx, "committing")
+	if err != nil {
+		return err
+	}
+	return CreateCommit(ctx, message)
+}
+
+// Reset implements Repository, using the git binary.
+func (n *Native) Reset(ctx context.Context, ref string) error {
+	ctx, err := n.fallback(ctx, "resetting")
+	if err != nil {
+		return err
+	}
+	return Reset(ctx, ref)
+}
+
+// RemoteTagExists implements Repository, using the git binary.
+func (n *Native) RemoteTagExists(ctx context.Context, remote, tag string) (bool, error) {
+	ctx, err := n.fallback(ctx, "checking remote tags")
+	if err != nil {
+		return false, err
+	}
+	return RemoteTagExists(ctx, remote, tag)
+}
+
+// PushTag implements Repository, using the git binary.
+func (n *Native) PushTag(ctx context.Context, remote, tag string) error {
+	ctx, err := n.fallback(ctx, "pushing tags")
+	if err != nil {
+		return err
+	}
+	return PushTag(ctx, remote, tag)
+}
+
+// AddWorktree implements Repository, using the git binary.
+func (n *Native) AddWorktree(ctx context.Context, dir, ref string) error {
+	ctx, err := n.fallback(ctx, "creating worktrees")
+	if err != nil {
+		return err
+	}
+	return AddWorktree(ctx, dir, ref)
+}
+
+// RemoveWorktree implements Repository, using the git binary.
+func (n *Native) RemoveWorktree(ctx context.Context, dir string) error {
+	ctx, err := n.fallback(ctx, "removing worktrees")
+	if err != nil {
+		return err
+	}
+	return RemoveWorktree(ctx, dir)
+}
+
+// HooksDir implements Repository, using the git binary, as core.hooksPath is
+// not read.
+func (n *Native) HooksDir(ctx context.Context) (string, error) {
+	ctx, err := n.fallback(ctx, "finding the hooks directory")
+	if err != nil {
+		return "", err
+	}
+	return HooksDir(ctx)
+}
+
+// IsShallow implements Repository.
+func (n *Native) IsShallow(context.Context) (bool, error) {
+	return len(n.shallow) > 0, nil
+}
+
+// TagReachable implements Repository.
+func (n *Native) TagReachable(_ context.Context, tag string) (bool, error) {
+	sha, err := n.resolve("refs/tags/" + tag)
+	if err != nil {
+		return false, err
+	}
+	commit, err := n.peel(sha)
+	if err != nil {
+		return false, err
+	}
+	head, err := n.resolve("HEAD")
+	if err != nil {
+		return false, err
+	}
+	reachable, err := n.reachable(head)
+	if err != nil {
+		return false, err
+	}
+	return reachable[commit], nil
+}
+
+// Deepen implements Repository, fetching with the git binary.
+func (n *Native) Deepen(ctx context.Context, depth int) error {
+	ctx, err := n.fallback(ctx, "fetching")
+	if err != nil {
+		return err
+	}
+	if err := Deepen(ctx, depth); err != nil {
+		return err
+	}
+	return n.load()
+}
+
+// walk calls fn for every commit in the same range as Changelog, in the same
+// order git log uses.
+func (n *Native) walk(tag string, specs pathspecs, fn func(c *nativeCommit)) error {
+	head, err := n.resolve("HEAD")
+	if err != nil {
+		return err
+	}
+
+	exclude := map[string]bool{}
+	if tag != "" {
+		sha, err := n.resolve("refs/tags/" + tag)
+		if err != nil {
+			return fmt.Errorf("ambiguous argument '%s': unknown revision", ChangelogRange(tag))
+		}
+		commit, err := n.peel(sha)
+		if err != nil {
+			return err
+		}
+		exclude, err = n.reachable(commit)
+		if err != nil {
+			return err
+		}
+	}
+
+	queue := &commitQueue{}
+	seen := map[string]bool{}
+	push := func(sha string) error {
+		if seen[sha] || exclude[sha] {
+			return nil
+		}
+		seen[sha] = true
+		c, err := n.commit(sha)
+		if err != nil {
+			return err
+		}
+		heap.Push(queue, c)
+		return nil
+	}
+	if err := push(head); err != nil {
+		return err
+	}
+
+	for queue.Len() > 0 {
+		c := heap.Pop(queue).(*nativeCommit)
+		parents := n.parents(c)
+
+		if specs.empty() {
+			fn(c)
+			for _, p := range parents {
+				if err := push(p); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		switch len(parents) {
+		case 0:
+			changed, err := n.treeChanged("", c.tree, "", specs)
+			if err != nil {
+				return err
+			}
+			if changed {
+				fn(c)
+			}
+		case 1:
+			parent, err := n.commit(parents[0])
+			if err != nil {
+				return err
+			}
+			changed, err := n.treeChanged(parent.tree, c.tree, "", specs)
+			if err != nil {
+				return err
+			}
+			if changed {
+				fn(c)
+			}
+			if err := push(parents[0]); err != nil {
+				return err
+			}
+		default:
+			// like git log, follow only the first parent with the same
+			// content in the given paths, if any.
+			same := ""
+			for _, p := range parents {
+				parent, err := n.commit(p)
+				if err != nil {
+					return err
+				}
+				changed, err := n.treeChanged(parent.tree, c.tree, "", specs)
+				if err != nil {
+					return err
+				}
+				if !changed {
+					same = p
+					break
+				}
+			}
+			if same != "" {
+				if err := push(same); err != nil {
+					return err
+				}
+				continue
+			}
+			fn(c)
+			for _, p := range parents {
+				if err := push(p); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// parents returns the parents of the given commit, or none if it is the
+// boundary of a shallow clone.
+func (n *Native) parents(c *nativeCommit) []string {
+	if n.shallow[c.sha] {
+		return nil
+	}
+	return c.parents
+}
+
+// reachable returns the commits reachable from the given one.
+func (n *Native) reachable(sha string) (map[string]bool, error) {
+	result := map[string]bool{}
+	stack := []string{sha}
+	for len(stack) > 0 {
+		sha := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if result[sha] {
+			continue
+		}
+		result[sha] = true
+		c, err := n.commit(sha)
+		if err != nil {
+			return nil, err
+		}
+		stack = append(stack, n.parents(c)...)
+	}
+	return result, nil
+}
+
+// treeChanged returns true if any file matching the given pathspecs differs
+// between the given trees. An empty tree SHA is an empty tree.
+func (n *Native) treeChanged(before, after, dir string, specs pathspecs) (bool, error) {
+	if before == after {
+		return false, nil
+	}
+	a, err := n.tree(before)
+	if err != nil {
+		return false, err
+	}
+	b, err := n.tree(after)
+	if err != nil {
+		return false, err
+	}
+
+	names := make(map[string]bool, len(a)+len(b))
+	for name := range a {
+		names[name] = true
+	}
+	for name := range b {
+		names[name] = true
+	}
+	for name := range names {
+		ea, eb := a[name], b[name]
+		if ea == eb {
+			continue
+		}
+		p := name
+		if dir != "" {
+			p = dir + "/" + name
+		}
+
+		if ea.isDir() || eb.isDir() {
+			if !specs.mayContain(p) {
+				continue
+			}
+			var ta, tb string
+			if ea.isDir() {
+				ta = ea.sha
+			}
+			if eb.isDir() {
+				tb = eb.sha
+			}
+			changed, err := n.treeChanged(ta, tb, p, specs)
+			if err != nil || changed {
+				return changed, err
+			}
+			// a file replaced by a directory, or the other way around.
+			if (ea.sha != "" && !ea.isDir()) || (eb.sha != "" && !eb.isDir()) {
+				if specs.match(p) {
+					return true, nil
+				}
+			}
+			continue
+		}
+		if specs.match(p) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type treeEntry struct {
+	mode string
+	sha  string
+}
+
+func (e treeEntry) isDir() bool {
+	return e.mode == "40000"
+}
+
+func (n *Native) tree(sha string) (map[string]treeEntry, error) {
+	entries := map[string]treeEntry{}
+	if sha == "" {
+		return entries, nil
+	}
+	typ, data, err := n.objects.read(sha)
+	if err != nil {
+		return nil, err
+	}
+	if typ != "tree" {
+		return nil, fmt.Errorf("object %s is a %s, not a tree", sha, typ)
+	}
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if sp < 0 || nul < sp || len(data) < nul+21 {
+			return nil, fmt.Errorf("invalid tree %s", sha)
+		}
+		entries[string(data[sp+1:nul])] = treeEntry{
+			mode: string(data[:sp]),
+			sha:  hex.EncodeToString(data[nul+1 : nul+21]),
+		}
+		data = data[nul+21:]
+	}
+	return entries, nil
+}
+
+func (n *Native) commit(sha string) (*nativeCommit, error) {
+	if c, ok := n.commits[sha]; ok {
+		return c, nil
+	}
+	typ, data, err := n.objects.read(sha)
+	if err != nil {
+		return nil, err
+	}
+	if typ != "commit" {
+		return nil, fmt.Errorf("object %s is a %s, not a commit", sha, typ)
+	}
+
+	c := &nativeCommit{sha: sha}
+	headers, message, _ := bytes.Cut(data, []byte("\n\n"))
+	c.message = string(message)
+	for line := range strings.SplitSeq(string(headers), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			c.tree = value
+		case "parent":
+			c.parents = append(c.parents, value)
+		case "committer":
+			fields := strings.Fields(value)
+			if len(fields) >= 2 {
+				c.time, _ = strconv.ParseInt(fields[len(fields)-2], 10, 64)
+			}
+		}
+	}
+	n.commits[sha] = c
+	return c, nil
+}
+
+// peel returns the commit the given object points to, following annotated
+// tags.
+func (n *Native) peel(sha string) (string, error) {
+	for range 10 {
+		typ, data, err := n.objects.read(sha)
+		if err != nil {
+			return "", err
+		}
+		if typ != "tag" {
+			return sha, nil
+		}
+		object, ok := bytes.CutPrefix(data, []byte("object "))
+		if !ok || len(object) < 40 {
+			return "", fmt.Errorf("invalid tag %s", sha)
+		}
+		sha = string(object[:40])
+	}
+	return "", fmt.Errorf("too many nested tags in %s", sha)
+}
+
+// resolve returns the SHA the given ref points to.
+func (n *Native) resolve(ref string) (string, error) {
+	for range 10 {
+		dir := n.commonDir
+		if ref == "HEAD" {
+			dir = n.gitDir
+		}
+		bts, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(ref)))
+		if errors.Is(err, fs.ErrNotExist) {
+			packed, err := n.packedRefs()
+			if err != nil {
+				return "", err
+			}
+			sha, ok := packed[ref]
+			if !ok {
+				return "", fmt.Errorf("ambiguous argument '%s': %w", ref, errUnknownRevision)
+			}
+			return sha, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		content := strings.TrimSpace(string(bts))
+		target, ok := strings.CutPrefix(content, "ref: ")
+		if !ok {
+			return content, nil
+		}
+		ref = target
+	}
+	return "", fmt.Errorf("too many symbolic refs resolving %s", ref)
+}
+
+// tagRefs returns the SHA of every tag, by name.
+func (n *Native) tagRefs() (map[string]string, error) {
+	packed, err := n.packedRefs()
+	if err != nil {
+		return nil, err
+	}
+	tags := map[string]string{}
+	for ref, sha := range packed {
+		if name, ok := strings.CutPrefix(ref, "refs/tags/"); ok {
+			tags[name] = sha
+		}
+	}
+
+	root := filepath.Join(n.commonDir, "refs", "tags")
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		bts, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		tags[filepath.ToSlash(name)] = strings.TrimSpace(string(bts))
+		return nil
+	})
+	return tags, err
+}
+
+func (n *Native) packedRefs() (map[string]string, error) {
+	refs := map[string]string{}
+	bts, err := os.ReadFile(filepath.Join(n.commonDir, "packed-refs"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return refs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for line := range strings.SplitSeq(string(bts), "\n") {
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		sha, ref, ok := strings.Cut(line, " ")
+		if ok {
+			refs[ref] = sha
+		}
+	}
+	return refs, nil
+}
+
+// identity returns the committer identity, as "name <email>", from the git
+// configuration or the environment.
+// writeRef creates the given ref pointing to sha, with the same lock file
+// protocol git uses, so concurrent writers fail instead of racing, and readers
+// never see a partially written ref.
+func writeRef(ref, sha string) (err error) {
+	if err := os.MkdirAll(filepath.Dir(ref), 0o755); err != nil {
+		return err
+	}
+	lock := ref + ".lock"
+	f, err := os.OpenFile(lock, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to lock %s: %w", ref, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(lock)
+		}
+	}()
+	if _, err := os.Stat(ref); err == nil {
+		return fmt.Errorf("%s already exists", ref)
+	}
+	if _, err := f.WriteString(sha + "\n"); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(lock, ref)
+}
+
+func (n *Native) identity() (string, error) {
+	var files []string
+	if config, err := os.UserConfigDir(); err == nil {
+		files = append(files, filepath.Join(config, "git", "config"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, ".gitconfig"))
+	}
+	files = append(files, filepath.Join(n.commonDir, "config"))
+
+	var name, email string
+	for _, file := range files {
+		fname, femail := readUserConfig(file)
+		if fname != "" {
+			name = fname
+		}
+		if femail != "" {
+			email = femail
+		}
+	}
+	if env := os.Getenv("GIT_COMMITTER_NAME"); env != "" {
+		name = env
+	}
+	if env := os.Getenv("GIT_COMMITTER_EMAIL"); env != "" {
+		email = env
+	}
+	if name == "" || email == "" {
+		return "", errors.New("committer identity unknown: set user.name and user.email")
+	}
+	return name + " <" + email + ">", nil
+}
+
+// readUserConfig returns user.name and user.email from the given git config
+// file, if set.
+func readUserConfig(file string) (name, email string) {
+	bts, err := os.ReadFile(file)
+	if err != nil {
+		return "", ""
+	}
+	section := ""
+	for line := range strings.SplitSeq(string(bts), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			section = strings.ToLower(strings.Trim(line, "[] \t"))
+			continue
+		}
+		if section != "user" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "name":
+			name = value
+		case "email":
+			email = value
+		}
+	}
+	return name, email
+}
+
+// commitQueue orders commits by committer date, newest first, and then by
+// insertion order, as git log does.
+type commitQueue struct {
+	items []*nativeCommit
+	order map[*nativeCommit]int
+	count int
+}
+
+func (q *commitQueue) Len() int { return len(q.items) }
+
+func (q *commitQueue) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	if a.time != b.time {
+		return a.time > b.time
+	}
+	return q.order[a] < q.order[b]
+}
+
+func (q *commitQueue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+
+func (q *commitQueue) Push(x any) {
+	if q.order == nil {
+		q.order = map[*nativeCommit]int{}
+	}
+	c := x.(*nativeCommit)
+	q.count++
+	q.order[c] = q.count
+	q.items = append(q.items, c)
+}
+
+func (q *commitQueue) Pop() any {
+	c := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return c
+}
+
+// objectStore reads objects from loose files and packfiles.
+type objectStore struct {
+	dirs  []string
+	packs []*packfile
+}
+
+func (s *objectStore) open(dir string) error {
+	if err := s.add(dir); err != nil {
+		return err
+	}
+	// alternates point to other object directories, e.g. in clones made
+	// with --shared or --reference.
+	bts, err := os.ReadFile(filepath.Join(dir, "info", "alternates"))
+	if err != nil {
+		return nil //nolint:nilerr
+	}
+	for alt := range strings.SplitSeq(string(bts), "\n") {
+		alt = strings.TrimSpace(alt)
+		if alt == "" || alt[0] == '#' {
+			continue
+		}
+		if !filepath.IsAbs(alt) {
+			alt = filepath.Join(dir, alt)
+		}
+		if err := s.add(alt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *objectStore) add(dir string) error {
+	s.dirs = append(s.dirs, dir)
+	idxs, err := filepath.Glob(filepath.Join(dir, "pack", "*.idx"))
+	if err != nil {
+		return err
+	}
+	for _, idx := range idxs {
+		p, err := openPackfile(idx)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", idx, err)
+		}
+		s.packs = append(s.packs, p)
+	}
+	return nil
+}
+
+// read returns the type and contents of the object with the given SHA.
+func (s *objectStore) read(sha string) (string, []byte, error) {
+	id, err := hex.DecodeString(sha)
+	if err != nil || len(id) != sha1.Size {
+		return "", nil, fmt.Errorf("invalid object name: %q", sha)
+	}
+	for _, p := range s.packs {
+		if offset, ok := p.find(id); ok {
+			return p.read(offset, s)
+		}
+	}
+	for _, dir := range s.dirs {
+		typ, data, err := readLoose(filepath.Join(dir, sha[:2], sha[2:]))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		return typ, data, err
+	}
+	return "", nil, fmt.Errorf("object %s not found", sha)
+}
+
+func readLoose(file string) (string, []byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return "", nil, err
+	}
+	defer zr.Close()
+	bts, err := io.ReadAll(zr)
+	if err != nil {
+		return "", nil, err
+	}
+	header, data, ok := bytes.Cut(bts, []byte{0})
+	if !ok {
+		return "", nil, fmt.Errorf("invalid object %s", file)
+	}
+	typ, _, _ := strings.Cut(string(header), " ")
+	return typ, data, nil
+}
+
+// writeLoose writes a loose object with the given type and contents, and
+// returns its SHA.
+func (s *objectStore) writeLoose(typ string, data []byte) (string, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %d\x00", typ, len(data))
+	buf.Write(data)
+	sum := sha1.Sum(buf.Bytes()) //nolint:gosec
+	sha := hex.EncodeToString(sum[:])
+
+	file := filepath.Join(s.dirs[0], sha[:2], sha[2:])
+	if _, err := os.Stat(file); err == nil {
+		return sha, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return "", err
+	}
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(buf.Bytes()); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return sha, os.WriteFile(file, compressed.Bytes(), 0o444)
+}
+
+// packfile is a git packfile and its version 2 index.
+type packfile struct {
+	path    string
+	fanout  [256]uint32
+	ids     []byte
+	offsets []byte
+	large   []byte
+	cache   map[int64]packObject
+}
+
+type packObject struct {
+	typ  string
+	data []byte
+}
+
+var packTypes = map[byte]string{1: "commit", 2: "tree", 3: "blob", 4: "tag"}
+
+func openPackfile(idx string) (*packfile, error) {
+	bts, err := os.ReadFile(idx)
+	if err != nil {
+		return nil, err
+	}
+	if len(bts) < 8+256*4 || !bytes.Equal(bts[:4], []byte{0xff, 't', 'O', 'c'}) || binary.BigEndian.Uint32(bts[4:]) != 2 {
+		return nil, errors.New("unsupported pack index version")
+	}
+	p := &packfile{
+		path:  strings.TrimSuffix(idx, ".idx") + ".pack",
+		cache: map[int64]packObject{},
+	}
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(bts[8+i*4:])
+	}
+	count := int(p.fanout[255])
+	pos := 8 + 256*4
+	if len(bts) < pos+count*(20+4+4) {
+		return nil, errors.New("truncated pack index")
+	}
+	p.ids = bts[pos : pos+count*20]
+	pos += count * 20
+	pos += count * 4 // crc32s
+	p.offsets = bts[pos : pos+count*4]
+	pos += count * 4
+	p.large = bts[pos:]
+	return p, nil
+}
+
+func (p *packfile) find(id []byte) (int64, bool) {
+	lo := uint32(0)
+	if id[0] > 0 {
+		lo = p.fanout[id[0]-1]
+	}
+	hi := p.fanout[id[0]]
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		switch c := bytes.Compare(p.ids[mid*20:mid*20+20], id); {
+		case c == 0:
+			offset := int64(binary.BigEndian.Uint32(p.offsets[mid*4:]))
+			if offset&0x80000000 != 0 {
+				i := int(offset & 0x7fffffff)
+				if len(p.large) < i*8+8 {
+					return 0, false
+				}
+				offset = int64(binary.BigEndian.Uint32(p.large[i*8:]))<<32 | int64(binary.BigEndian.Uint32(p.large[i*8+4:]))
+			}
+			return offset, true
+		case c < 0:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return 0, false
+}
+
+// read returns the object at the given offset, resolving deltas.
+func (p *packfile) read(offset int64, store *objectStore) (string, []byte, error) {
+	if obj, ok := p.cache[offset]; ok {
+		return obj.typ, obj.data, nil
+	}
+
+	f, err := os.Open(p.path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(io.NewSectionReader(f, offset, 1<<62))
+
+	c, err := r.ReadByte()
+	if err != nil {
+		return "", nil, err
+	}
+	kind := (c >> 4) & 7
+	for c&0x80 != 0 { // the size is not needed, zlib knows where to stop.
+		if c, err = r.ReadByte(); err != nil {
+			return "", nil, err
+		}
+	}
+
+	var baseType string
+	var base []byte
+	switch kind {
+	case 6: // offset delta
+		c, err := r.ReadByte()
+		if err != nil {
+			return "", nil, err
+		}
+		rel := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = r.ReadByte(); err != nil {
+				return "", nil, err
+			}
+			rel = (rel+1)<<7 | int64(c&0x7f)
+		}
+		baseType, base, err = p.read(offset-rel, store)
+		if err != nil {
+			return "", nil, err
+		}
+	case 7: // reference delta
+		id := make([]byte, 20)
+		if _, err := io.ReadFull(r, id); err != nil {
+			return "", nil, err
+		}
+		baseType, base, err = store.read(hex.EncodeToString(id))
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return "", nil, err
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return "", nil, err
+	}
+
+	typ := packTypes[kind]
+	if base != nil {
+		typ = baseType
+		if data, err = applyDelta(base, data); err != nil {
+			return "", nil, fmt.Errorf("invalid delta at %d in %s: %w", offset, p.path, err)
+		}
+	}
+	if typ == "" {
+		return "", nil, fmt.Errorf("invalid object type %d at %d in %s", kind, offset, p.path)
+	}
+
+	// trees and blobs are often delta bases, commits are cached by the
+	// repository already.
+	if typ != "commit" {
+		if len(p.cache) > 4096 {
+			clear(p.cache)
+		}
+		p.cache[offset] = packObject{typ, data}
+	}
+	return typ, data, nil
+}
+
+func applyDelta(base, delta []byte) ([]byte, error) {
+	varint := func() (int, error) {
+		n, shift := 0, 0
+		for {
+			if len(delta) == 0 {
+				return 0, io.ErrUnexpectedEOF
+			}
+			c := delta[0]
+			delta = delta[1:]
+			n |= int(c&0x7f) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				return n, nil
+			}
+		}
+	}
+	baseSize, err := varint()
+	if err != nil {
+		return nil, err
+	}
+	if baseSize != len(base) {
+		return nil, errors.New("base size mismatch")
+	}
+	size, err := varint()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 0, size)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+		if op&0x80 == 0 {
+			// insert the next op bytes.
+			if op == 0 || int(op) > len(delta) {
+				return nil, errors.New("invalid insert")
+			}
+			result = append(result, delta[:op]...)
+			delta = delta[op:]
+			continue
+		}
+
+		// copy from the base.
+		var offset, length int
+		for i := range 7 {
+			if op&(1<<i) == 0 {
+				continue
+			}
+			if len(delta) == 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			if i < 4 {
+				offset |= int(delta[0]) << (8 * i)
+			} else {
+				length |= int(delta[0]) << (8 * (i - 4))
+			}
+			delta = delta[1:]
+		}
+		if length == 0 {
+			length = 0x10000
+		}
+		if offset+length > len(base) {
+			return nil, errors.New("copy out of bounds")
+		}
+		result = append(result, base[offset:offset+length]...)
+	}
+	if len(result) != size {
+		return nil, errors.New("result size mismatch")
+	}
+	return result, nil
+}
@@ -0,0 +1,178 @@
+package git
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// pathspecs are parsed git pathspecs, relative to the repository root.
+type pathspecs struct {
+	include []pattern
+	exclude []pattern
+}
+
+// pattern is a single pathspec, either a path prefix, or a wildcard pattern
+// where * also matches slashes, as git's default pathspecs.
+type pattern struct {
+	prefix string
+	glob   *regexp.Regexp
+}
+
+// parsePathspecs parses the given pathspecs, as given to git log from the cwd
+// directory, relative to the root of the repository.
+//
+// Only the top and exclude magic words, and their short forms, are
+// supported.
+func parsePathspecs(specs []string, root, cwd string) pathspecs {
+	var result pathspecs
+	for _, spec := range specs {
+		var top, exclude bool
+		switch {
+		case strings.HasPrefix(spec, ":("):
+			magic, rest, _ := strings.Cut(spec[2:], ")")
+			for word := range strings.SplitSeq(magic, ",") {
+				switch strings.TrimSpace(word) {
+				case "top":
+					top = true
+				case "exclude":
+					exclude = true
+				}
+			}
+			spec = rest
+		case strings.HasPrefix(spec, ":"):
+			spec = spec[1:]
+			for spec != "" && strings.ContainsRune("/!^", rune(spec[0])) {
+				switch spec[0] {
+				case '/':
+					top = true
+				default:
+					exclude = true
+				}
+				spec = spec[1:]
+			}
+			spec = strings.TrimPrefix(spec, ":")
+		}
+
+		switch {
+		case filepath.IsAbs(spec):
+			rel, err := filepath.Rel(root, spec)
+			if err == nil {
+				spec = filepath.ToSlash(rel)
+			}
+		case !top:
+			spec = path.Join(cwd, filepath.ToSlash(spec))
+		}
+		spec = strings.Trim(path.Clean("/"+spec), "/")
+
+		p := pattern{prefix: spec}
+		if strings.ContainsAny(spec, "*?[") {
+			p.glob = compileWildcard(spec)
+		}
+		if exclude {
+			result.exclude = append(result.exclude, p)
+		} else {
+			result.include = append(result.include, p)
+		}
+	}
+	if len(result.include) == 0 && len(result.exclude) > 0 {
+		result.include = []pattern{{}}
+	}
+	return result
+}
+
+func compileWildcard(spec string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(spec); i++ {
+		switch c := spec[i]; c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		case '[':
+			end := strings.IndexByte(spec[i:], ']')
+			if end < 0 {
+				sb.WriteString(regexp.QuoteMeta(spec[i:]))
+				i = len(spec)
+				continue
+			}
+			class := spec[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("(/.*)?$")
+	return regexp.MustCompile(sb.String())
+}
+
+func (p pattern) match(file string) bool {
+	if p.glob != nil {
+		return p.glob.MatchString(file)
+	}
+	return p.prefix == "" || file == p.prefix || strings.HasPrefix(file, p.prefix+"/")
+}
+
+// mayContain returns true if files in the given directory may match.
+func (p pattern) mayContain(dir string) bool {
+	return p.glob != nil || p.match(dir) || strings.HasPrefix(p.prefix, dir+"/")
+}
+
+// empty returns true if there are no pathspecs, so every file matches.
+func (ps pathspecs) empty() bool {
+	return len(ps.include) == 0
+}
+
+func (ps pathspecs) match(file string) bool {
+	if ps.empty() {
+		return true
+	}
+	for _, p := range ps.exclude {
+		if p.match(file) {
+			return false
+		}
+	}
+	for _, p := range ps.include {
+		if p.match(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// mayContain returns true if files in the given directory may match.
+func (ps pathspecs) mayContain(dir string) bool {
+	if ps.empty() {
+		return true
+	}
+	for _, p := range ps.exclude {
+		if p.glob == nil && p.match(dir) {
+			return false
+		}
+	}
+	for _, p := range ps.include {
+		if p.mayContain(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPathspecs returns true if any of the given files, relative to the
+// repository root, matches the given pathspecs, as git log does when run from
+// the repository root.
+func MatchPathspecs(files, specs []string) bool {
+	ps := parsePathspecs(specs, "/", "")
+	for _, file := range files {
+		if ps.match(file) {
+			return true
+		}
+	}
+	return false
+}
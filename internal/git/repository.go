@@ -0,0 +1,213 @@
+package git
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Repository is a git repository svu reads the history from, and creates tags
+// in.
+type Repository interface {
+	// Root returns the path of the working tree, or an empty string if there
+	// is none.
+	Root(ctx context.Context) string
+	// ListTags returns the tags of the repository, sorted by tagSort, one of
+	// TagSortSemver, TagSortDate or TagSortTopo. If tagMode is
+	// TagModeCurrent, only tags merged into HEAD are returned.
+	ListTags(ctx context.Context, tagMode, tagSort string) ([]string, error)
+	// DescribeTag returns the first tag matching the given pattern, sorted by
+	// tagSort.
+	DescribeTag(ctx context.Context, tagMode, tagSort string, pattern string) (string, error)
+	// Changelog returns the commits since the given tag, newest first, that
+	// changed the given directories, if any.
+	Changelog(ctx context.Context, tag string, dirs []string) ([]Commit, error)
+	// HeadSHA returns the SHA of the commit HEAD points to, or an empty
+	// string if there are no commits yet.
+	HeadSHA(ctx context.Context) (string, error)
+	// HeadTime returns the committer date of the commit HEAD points to.
+	HeadTime(ctx context.Context) (time.Time, error)
+	// IsDirty returns true if the working tree has uncommitted changes.
+	IsDirty(ctx context.Context) (bool, error)
+	// CurrentBranch returns the name of the branch HEAD points to, or an
+	// empty string if HEAD is detached.
+	CurrentBranch(ctx context.Context) (string, error)
+	// CountCommits returns the number of commits since the given tag, or the
+	// number of commits in HEAD if tag is empty.
+	CountCommits(ctx context.Context, tag string) (int, error)
+	// TagExists returns true if the given tag exists in the repository.
+	TagExists(ctx context.Context, tag string) (bool, error)
+	// CreateTag creates the given tag pointing to HEAD, annotated if a
+	// message is given, and signed if sign is true.
+	CreateTag(ctx context.Context, tag, message string, sign bool) error
+	// DeleteTag deletes the given tag.
+	DeleteTag(ctx context.Context, tag string) error
+	// Add stages the given paths, relative to the repository root.
+	Add(ctx context.Context, paths []string) error
+	// CreateCommit commits the staged changes with the given message.
+	CreateCommit(ctx context.Context, message string) error
+	// Reset points HEAD to the given commit, and resets the index to it,
+	// keeping the working tree as is.
+	Reset(ctx context.Context, ref string) error
+	// RemoteTagExists returns true if the given remote has the given tag.
+	RemoteTagExists(ctx context.Context, remote, tag string) (bool, error)
+	// PushTag pushes the given tag to the given remote.
+	PushTag(ctx context.Context, remote, tag string) error
+	// AddWorktree checks out the given ref, detached, in a new worktree at
+	// the given directory.
+	AddWorktree(ctx context.Context, dir, ref string) error
+	// RemoveWorktree removes the worktree at the given directory.
+	RemoveWorktree(ctx context.Context, dir string) error
+	// HooksDir returns the absolute path of the hooks directory.
+	HooksDir(ctx context.Context) (string, error)
+	// IsShallow returns true if the repository is a shallow clone.
+	IsShallow(ctx context.Context) (bool, error)
+	// TagReachable returns true if the given tag points to a commit
+	// reachable from HEAD.
+	TagReachable(ctx context.Context, tag string) (bool, error)
+	// Deepen fetches depth more commits of history, and the tags.
+	Deepen(ctx context.Context, depth int) error
+}
+
+// Exec is the Repository in the current directory, using the git binary.
+type Exec struct{}
+
+var _ Repository = Exec{}
+
+func (Exec) Root(ctx context.Context) string { return Root(ctx) }
+
+func (Exec) ListTags(ctx context.Context, tagMode, tagSort string) ([]string, error) {
+	return ListTags(ctx, tagMode, tagSort)
+}
+
+func (Exec) DescribeTag(ctx context.Context, tagMode, tagSort string, pattern string) (string, error) {
+	return DescribeTag(ctx, tagMode, tagSort, pattern)
+}
+
+func (Exec) Changelog(ctx context.Context, tag string, dirs []string) ([]Commit, error) {
+	return Changelog(ctx, tag, dirs)
+}
+
+func (Exec) HeadSHA(ctx context.Context) (string, error) { return HeadSHA(ctx) }
+
+func (Exec) HeadTime(ctx context.Context) (time.Time, error) { return HeadTime(ctx) }
+
+func (Exec) IsDirty(ctx context.Context) (bool, error) { return IsDirty(ctx) }
+
+func (Exec) CurrentBranch(ctx context.Context) (string, error) { return CurrentBranch(ctx) }
+
+func (Exec) CountCommits(ctx context.Context, tag string) (int, error) {
+	return CountCommits(ctx, tag)
+}
+
+func (Exec) TagExists(ctx context.Context, tag string) (bool, error) {
+	return TagExists(ctx, tag)
+}
+
+func (Exec) CreateTag(ctx context.Context, tag, message string, sign bool) error {
+	return CreateTag(ctx, tag, message, sign)
+}
+
+func (Exec) DeleteTag(ctx context.Context, tag string) error { return DeleteTag(ctx, tag) }
+
+func (Exec) Add(ctx context.Context, paths []string) error { return Add(ctx, paths) }
+
+func (Exec) CreateCommit(ctx context.Context, message string) error {
+	return CreateCommit(ctx, message)
+}
+
+func (Exec) Reset(ctx context.Context, ref string) error { return Reset(ctx, ref) }
+
+func (Exec) RemoteTagExists(ctx context.Context, remote, tag string) (bool, error) {
+	return RemoteTagExists(ctx, remote, tag)
+}
+
+func (Exec) PushTag(ctx context.Context, remote, tag string) error {
+	return PushTag(ctx, remote, tag)
+}
+
+func (Exec) AddWorktree(ctx context.Context, dir, ref string) error {
+	return AddWorktree(ctx, dir, ref)
+}
+
+func (Exec) RemoveWorktree(ctx context.Context, dir string) error {
+	return RemoveWorktree(ctx, dir)
+}
+
+func (Exec) HooksDir(ctx context.Context) (string, error) { return HooksDir(ctx) }
+
+func (Exec) IsShallow(ctx context.Context) (bool, error) { return IsShallow(ctx) }
+
+func (Exec) TagReachable(ctx context.Context, tag string) (bool, error) {
+	return TagReachable(ctx, tag)
+}
+
+func (Exec) Deepen(ctx context.Context, depth int) error { return Deepen(ctx, depth) }
+
+// SortTags sorts the given tags by version, newest first, the same way
+// git tag --sort=-version:refname does with versionsort.suffix set to "-".
+func SortTags(tags []string) {
+	slices.SortStableFunc(tags, func(a, b string) int {
+		return compareVersions(b, a)
+	})
+}
+
+// compareVersions compares the given tags like git's versioncmp: numbers are
+// compared by value, and a "-" where the tags start to differ makes that tag
+// older, so v1.0.0-rc1 comes before v1.0.0.
+func compareVersions(a, b string) int {
+	off := 0
+	for off < len(a) && off < len(b) && a[off] == b[off] {
+		off++
+	}
+	if off == len(a) && off == len(b) {
+		return 0
+	}
+	prereleaseA := off < len(a) && a[off] == '-'
+	prereleaseB := off < len(b) && b[off] == '-'
+	switch {
+	case prereleaseA && !prereleaseB:
+		return -1
+	case prereleaseB && !prereleaseA:
+		return 1
+	}
+
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			var na, nb string
+			na, a = splitNumber(a)
+			nb, b = splitNumber(b)
+			if c := compareNumbers(na, nb); c != 0 {
+				return c
+			}
+			continue
+		}
+		if a[0] != b[0] {
+			return int(a[0]) - int(b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) - len(b)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func splitNumber(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+func compareNumbers(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,84 @@
+package git
+
+import (
+	"cmp"
+	"slices"
+)
+
+const (
+	// TagSortSemver sorts tags by version, newest first.
+	TagSortSemver = "semver"
+	// TagSortDate sorts tags by creation date, newest first: the tagger date
+	// of annotated tags, and the commit date of lightweight ones.
+	TagSortDate = "date"
+	// TagSortTopo sorts tags by the distance from HEAD of the commits they
+	// point to, nearest first, like git describe.
+	TagSortTopo = "topo"
+)
+
+// SortTagsByDate sorts the given tags by their creation date, as unix
+// timestamps, newest first. Tags created at the same time are sorted by
+// version.
+func SortTagsByDate(tags []string, dates map[string]int64) {
+	slices.SortStableFunc(tags, func(a, b string) int {
+		if c := cmp.Compare(dates[b], dates[a]); c != 0 {
+			return c
+		}
+		return compareVersions(b, a)
+	})
+}
+
+// SortTagsByDistance sorts the given tags by the number of commits between
+// HEAD and the commits they point to, nearest first. Tags not in distances
+// are not reachable from HEAD, and come last. Tags at the same distance are
+// sorted by version.
+func SortTagsByDistance(tags []string, distances map[string]int) {
+	slices.SortStableFunc(tags, func(a, b string) int {
+		da, oka := distances[a]
+		db, okb := distances[b]
+		switch {
+		case oka && !okb:
+			return -1
+		case okb && !oka:
+			return 1
+		}
+		if c := cmp.Compare(da, db); c != 0 {
+			return c
+		}
+		return compareVersions(b, a)
+	})
+}
+
+// distances returns the distance of every commit reachable from head, given
+// the parents of each commit, following the shortest path.
+func distances(head string, parents func(sha string) ([]string, error)) (map[string]int, error) {
+	result := map[string]int{head: 0}
+	queue := []string{head}
+	for len(queue) > 0 {
+		sha := queue[0]
+		queue = queue[1:]
+		ps, err := parents(sha)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range ps {
+			if _, ok := result[p]; ok {
+				continue
+			}
+			result[p] = result[sha] + 1
+			queue = append(queue, p)
+		}
+	}
+	return result, nil
+}
+
+// tagDistances maps the distance of every commit to the tags pointing to it.
+func tagDistances(commits map[string]int, tags map[string]string) map[string]int {
+	result := map[string]int{}
+	for tag, commit := range tags {
+		if d, ok := commits[commit]; ok {
+			result[tag] = d
+		}
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+package svu
+
+import (
+	"fmt"
+
+	"github.com/gobwas/glob"
+)
+
+const (
+	// ExceedError fails when the history asks for a bigger bump than a
+	// branch allows.
+	ExceedError = "error"
+	// ExceedWarn caps the bump to the one a branch allows, with a warning.
+	ExceedWarn = "warn"
+)
+
+// Branch is a policy applied when the current branch matches it, e.g. to
+// only allow patch releases in maintenance branches.
+type Branch struct {
+	// Match is a glob pattern the current branch name is matched against,
+	// e.g. "release/*".
+	Match string `mapstructure:"match"`
+	// MaxBump is the biggest bump allowed: major, minor, patch or none.
+	MaxBump string `mapstructure:"max_bump"`
+	// Pattern, if set, replaces the tag pattern, so only the tags of this
+	// branch are considered, e.g. "v1.*".
+	Pattern string `mapstructure:"tag.pattern"`
+	// OnExceed is what happens when the history asks for a bigger bump than
+	// MaxBump: error, the default, or warn.
+	OnExceed string `mapstructure:"on_exceed"`
+}
+
+// Options returns the given options with the branch bump limit and tag
+// pattern set.
+func (b Branch) Options(opts Options) Options {
+	opts.MaxBump = b.MaxBump
+	opts.OnExceed = b.OnExceed
+	if b.Pattern != "" {
+		opts.Pattern = b.Pattern
+	}
+	return opts
+}
+
+// FindBranch returns the first of the given branch policies matching the
+// current branch, if any. A detached HEAD matches none.
+func FindBranch(opts Options, branches []Branch) (Branch, bool, error) {
+	for _, b := range branches {
+		if _, err := ParseBump(b.MaxBump); b.MaxBump != "" && err != nil {
+			return Branch{}, false, fmt.Errorf("invalid branch %q: %w", b.Match, err)
+		}
+		switch b.OnExceed {
+		case "", ExceedError, ExceedWarn:
+		default:
+			return Branch{}, false, fmt.Errorf(
+				"invalid branch %q: invalid on_exceed: %q: valid options are %q and %q",
+				b.Match,
+				b.OnExceed,
+				ExceedError,
+				ExceedWarn,
+			)
+		}
+	}
+	if len(branches) == 0 {
+		return Branch{}, false, nil
+	}
+
+	name, err := repository(opts).CurrentBranch(opts.Ctx)
+	if err != nil {
+		return Branch{}, false, fmt.Errorf("failed to get current branch: %w", err)
+	}
+	if name == "" {
+		return Branch{}, false, nil
+	}
+	for _, b := range branches {
+		g, err := glob.Compile(b.Match)
+		if err != nil {
+			return Branch{}, false, fmt.Errorf("invalid branch %q: %w", b.Match, err)
+		}
+		if g.Match(name) {
+			return b, true, nil
+		}
+	}
+	return Branch{}, false, nil
+}
+
+// capBump returns the given bump capped to opts.MaxBump, if set. If the bump
+// exceeds it, and OnExceed is not ExceedWarn, it returns an error.
+func capBump(bump Bump, opts Options) (Bump, error) {
+	if opts.MaxBump == "" {
+		return bump, nil
+	}
+	limit, err := ParseBump(opts.MaxBump)
+	if err != nil {
+		return bump, err
+	}
+	if bump <= limit {
+		return bump, nil
+	}
+	if opts.OnExceed != ExceedWarn {
+		return bump, fmt.Errorf(
+			"found %s change, but the max_bump of the branch is %s: set on_exceed to warn to cap the bump instead",
+			bump,
+			limit,
+		)
+	}
+	warnf(opts, "found %s change, but the max_bump of the branch is %s: capping the bump", bump, limit)
+	return limit, nil
+}
@@ -0,0 +1,94 @@
+package svu
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+	"github.com/caarlos0/svu/v3/pkg/svu/svutest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindBranch(t *testing.T) {
+	repo := svutest.New()
+	opts := Options{Ctx: t.Context(), Repository: repo}
+	branches := []Branch{
+		{Match: "release/*", MaxBump: "patch", Pattern: "v1.*"},
+		{Match: "next", MaxBump: "minor", OnExceed: ExceedWarn},
+	}
+
+	for name, expected := range map[string]string{
+		"release/1.x": "release/*",
+		"next":        "next",
+	} {
+		t.Run(name, func(t *testing.T) {
+			repo.Branch = name
+			b, ok, err := FindBranch(opts, branches)
+			require.NoError(t, err)
+			require.True(t, ok)
+			require.Equal(t, expected, b.Match)
+		})
+	}
+
+	for _, name := range []string{"main", ""} {
+		t.Run("no match "+name, func(t *testing.T) {
+			repo.Branch = name
+			_, ok, err := FindBranch(opts, branches)
+			require.NoError(t, err)
+			require.False(t, ok)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := FindBranch(opts, []Branch{{Match: "main", MaxBump: "huge"}})
+		require.ErrorContains(t, err, `invalid branch "main": invalid bump: "huge"`)
+		_, _, err = FindBranch(opts, []Branch{{Match: "main", OnExceed: "ignore"}})
+		require.ErrorContains(t, err, `invalid on_exceed: "ignore"`)
+	})
+
+	t.Run("options", func(t *testing.T) {
+		opts := branches[0].Options(Options{Pattern: "v*"})
+		require.Equal(t, "patch", opts.MaxBump)
+		require.Equal(t, "v1.*", opts.Pattern)
+		opts = branches[1].Options(opts)
+		require.Equal(t, ExceedWarn, opts.OnExceed)
+		require.Equal(t, "v1.*", opts.Pattern)
+	})
+}
+
+func TestMaxBump(t *testing.T) {
+	repo := svutest.New()
+	repo.Commit("chore: init")
+	repo.Tag("v1.2.3")
+	repo.Commit("feat: foo")
+
+	var stderr bytes.Buffer
+	opts := Options{
+		Ctx:        t.Context(),
+		Action:     Next,
+		Prefix:     "v",
+		TagMode:    git.TagModeCurrent,
+		Repository: repo,
+		MaxBump:    "patch",
+		Stderr:     &stderr,
+	}
+
+	_, err := Version(opts)
+	require.EqualError(t, err, "could not get next tag: 'v1.2.3': found minor change, but the max_bump of the branch is patch: set on_exceed to warn to cap the bump instead")
+
+	opts.Action = Major
+	_, err = Version(opts)
+	require.ErrorContains(t, err, "found major change")
+
+	opts.Action = Next
+	opts.OnExceed = ExceedWarn
+	v, err := Version(opts)
+	require.NoError(t, err)
+	require.Equal(t, "v1.2.4", v)
+	require.Equal(t, "warning: found minor change, but the max_bump of the branch is patch: capping the bump\n", stderr.String())
+
+	opts.MaxBump = "minor"
+	v, err = Version(opts)
+	require.NoError(t, err)
+	require.Equal(t, "v1.3.0", v)
+}
@@ -0,0 +1,243 @@
+package svu
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+)
+
+const (
+	SchemeSemver = "semver"
+	SchemeCalver = "calver"
+)
+
+// DefaultCalverFormat is the calendar version format used when none is given.
+const DefaultCalverFormat = "YYYY.0M.MICRO"
+
+// calverTokens are the calendar version format tokens, longest first, so
+// YYYY is matched before YY.
+var calverTokens = []string{"YYYY", "MICRO", "YY", "0Y", "MM", "0M", "WW", "0W", "DD", "0D"}
+
+var calverPatterns = map[string]string{
+	"YYYY":  `(\d{4})`,
+	"YY":    `(\d{1,3})`,
+	"0Y":    `(\d{2,3})`,
+	"MM":    `(\d{1,2})`,
+	"0M":    `(\d{2})`,
+	"WW":    `(\d{1,2})`,
+	"0W":    `(\d{2})`,
+	"DD":    `(\d{1,2})`,
+	"0D":    `(\d{2})`,
+	"MICRO": `(\d+)`,
+}
+
+// calverPart is either a format token or a literal.
+type calverPart struct {
+	token   string
+	literal string
+}
+
+func parseCalverFormat(format string) ([]calverPart, error) {
+	var parts []calverPart
+	for i := 0; i < len(format); {
+		token := ""
+		for _, t := range calverTokens {
+			if strings.HasPrefix(format[i:], t) {
+				token = t
+				break
+			}
+		}
+		if token != "" {
+			parts = append(parts, calverPart{token: token})
+			i += len(token)
+			continue
+		}
+		if n := len(parts); n > 0 && parts[n-1].token == "" {
+			parts[n-1].literal += format[i : i+1]
+		} else {
+			parts = append(parts, calverPart{literal: format[i : i+1]})
+		}
+		i++
+	}
+	for _, p := range parts {
+		if p.token != "" && p.token != "MICRO" {
+			return parts, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid calver format %q: it must have at least one date token", format)
+}
+
+// calverValue returns the value of the given token at the given time.
+// Weekly formats use the ISO week-numbering year, so the last days of
+// December that belong to the first week of the next year do not render as
+// a version lower than the previous ones.
+func calverValue(token string, now time.Time, weekly bool) int {
+	year := now.Year()
+	if weekly {
+		year, _ = now.ISOWeek()
+	}
+	switch token {
+	case "YYYY":
+		return year
+	case "YY", "0Y":
+		return year - 2000
+	case "MM", "0M":
+		return int(now.Month())
+	case "WW", "0W":
+		_, week := now.ISOWeek()
+		return week
+	case "DD", "0D":
+		return now.Day()
+	}
+	return 0
+}
+
+// isWeekly reports whether the format has a week token.
+func isWeekly(parts []calverPart) bool {
+	for _, p := range parts {
+		if p.token == "WW" || p.token == "0W" {
+			return true
+		}
+	}
+	return false
+}
+
+func renderCalver(parts []calverPart, now time.Time, micro int) string {
+	weekly := isWeekly(parts)
+	var sb strings.Builder
+	for _, p := range parts {
+		switch p.token {
+		case "":
+			sb.WriteString(p.literal)
+		case "MICRO":
+			sb.WriteString(strconv.Itoa(micro))
+		case "0Y", "0M", "0W", "0D":
+			fmt.Fprintf(&sb, "%02d", calverValue(p.token, now, weekly))
+		default:
+			sb.WriteString(strconv.Itoa(calverValue(p.token, now, weekly)))
+		}
+	}
+	return sb.String()
+}
+
+// nextCalver returns the calendar version that follows previous at the given
+// time: the micro is incremented if the date did not change, and reset to
+// zero otherwise.
+func nextCalver(previous, format string, now time.Time) (string, error) {
+	parts, err := parseCalverFormat(format)
+	if err != nil {
+		return "", err
+	}
+	hasMicro := false
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, p := range parts {
+		if p.token == "" {
+			expr.WriteString(regexp.QuoteMeta(p.literal))
+			continue
+		}
+		hasMicro = hasMicro || p.token == "MICRO"
+		expr.WriteString(calverPatterns[p.token])
+	}
+	expr.WriteString(`(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?$`)
+
+	if previous == "" {
+		return renderCalver(parts, now, 0), nil
+	}
+
+	m := regexp.MustCompile(expr.String()).FindStringSubmatch(previous)
+	if m == nil {
+		return "", fmt.Errorf("version %q does not match calver format %q", previous, format)
+	}
+
+	weekly := isWeekly(parts)
+	sameDate := true
+	micro := 0
+	i := 1
+	for _, p := range parts {
+		if p.token == "" {
+			continue
+		}
+		value, err := strconv.Atoi(m[i])
+		if err != nil {
+			return "", fmt.Errorf("version %q does not match calver format %q: %w", previous, format, err)
+		}
+		i++
+		if p.token == "MICRO" {
+			micro = value
+			continue
+		}
+		if value != calverValue(p.token, now, weekly) {
+			sameDate = false
+		}
+	}
+
+	if !sameDate {
+		return renderCalver(parts, now, 0), nil
+	}
+	if !hasMicro {
+		return "", errors.New("calver format has no MICRO, and the date did not change since the last version")
+	}
+	return renderCalver(parts, now, micro+1), nil
+}
+
+// calver returns the current tag and the version computed from it using the
+// calendar versioning scheme, and the commits since the tag, or nil if they
+// were not read.
+func calver(opts Options, now time.Time) (string, string, []git.Commit, error) {
+	format := opts.CalverFormat
+	if format == "" {
+		format = DefaultCalverFormat
+	}
+
+	tag, err := repository(opts).DescribeTag(opts.Ctx, opts.TagMode, opts.TagSort, opts.Pattern)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to get current tag for repo: %w", err)
+	}
+	current := strings.TrimPrefix(tag, opts.Prefix)
+
+	switch opts.Action {
+	case Current:
+		if tag == "" {
+			return "", "", nil, errors.New("no calver tag found, and calver has no initial version: create the first tag with svu tag")
+		}
+		return tag, current, nil, nil
+	case Next:
+	default:
+		return "", "", nil, errors.New("only next and current are supported with the calver scheme")
+	}
+
+	var changes []git.Commit
+	if tag != "" && !opts.Always {
+		changes, err = repository(opts).Changelog(opts.Ctx, tag, opts.Directories)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("failed to get changelog: %w", err)
+		}
+		if len(changes) == 0 {
+			log.Println("found no changes")
+			return tag, current, changes, nil
+		}
+	}
+
+	next, err := nextCalver(current, format, now)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("could not get next tag: '%s': %w", tag, err)
+	}
+	if opts.PreRelease != "" {
+		next += "-" + opts.PreRelease
+	}
+	metadata, err := renderMetadata(tag, opts)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if metadata != "" {
+		next += "+" + metadata
+	}
+	return tag, next, changes, nil
+}
@@ -0,0 +1,104 @@
+package svu
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+	"github.com/caarlos0/svu/v3/pkg/svu/svutest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextCalver(t *testing.T) {
+	now := time.Date(2026, time.March, 7, 10, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name     string
+		previous string
+		format   string
+		expected string
+	}{
+		{"first", "", "YYYY.0M.MICRO", "2026.03.0"},
+		{"same month", "2026.03.4", "YYYY.0M.MICRO", "2026.03.5"},
+		{"new month", "2026.02.4", "YYYY.0M.MICRO", "2026.03.0"},
+		{"new year", "2025.03.4", "YYYY.0M.MICRO", "2026.03.0"},
+		{"short year", "26.3.1", "YY.MM.MICRO", "26.3.2"},
+		{"padded short year", "26.02.1", "0Y.0M.MICRO", "26.03.0"},
+		{"day", "2026.3.7.9", "YYYY.MM.DD.MICRO", "2026.3.7.10"},
+		{"padded day", "2026-03-06_1", "YYYY-0M-0D_MICRO", "2026-03-07_0"},
+		{"week", "2026.10.2", "YYYY.WW.MICRO", "2026.10.3"},
+		{"padded week", "2026.09.2", "YYYY.0W.MICRO", "2026.10.0"},
+		{"previous has prerelease", "2026.03.1-rc1+build", "YYYY.0M.MICRO", "2026.03.2"},
+		{"no micro", "2026.02", "YYYY.0M", "2026.03"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := nextCalver(tt.previous, tt.format, now)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, next)
+		})
+	}
+
+	t.Run("week across the year boundary", func(t *testing.T) {
+		// 2025-12-29 is in the first ISO week of 2026.
+		now := time.Date(2025, time.December, 29, 10, 0, 0, 0, time.UTC)
+		next, err := nextCalver("2025.52.3", "YYYY.WW.MICRO", now)
+		require.NoError(t, err)
+		require.Equal(t, "2026.1.0", next)
+
+		next, err = nextCalver(next, "YYYY.WW.MICRO", now.AddDate(0, 0, 3))
+		require.NoError(t, err)
+		require.Equal(t, "2026.1.1", next)
+
+		// formats without a week token keep the calendar year.
+		next, err = nextCalver("2025.12.3", "YYYY.0M.MICRO", now)
+		require.NoError(t, err)
+		require.Equal(t, "2025.12.4", next)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for name, args := range map[string][2]string{
+			"no date token":           {"", "MICRO"},
+			"previous does not match": {"1.2.3", "YYYY.0M.MICRO"},
+			"no micro, same date":     {"2026.03", "YYYY.0M"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := nextCalver(args[0], args[1], now)
+				require.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestCalver(t *testing.T) {
+	now := time.Date(2026, time.March, 7, 10, 0, 0, 0, time.UTC)
+	repo := svutest.New()
+	repo.Commit("chore: init")
+	opts := Options{
+		Ctx:        t.Context(),
+		Prefix:     "v",
+		TagMode:    git.TagModeAll,
+		Repository: repo,
+	}
+
+	t.Run("no tags", func(t *testing.T) {
+		opts := opts
+		opts.Action = Current
+		_, _, _, err := calver(opts, now)
+		require.ErrorContains(t, err, "no calver tag found")
+
+		opts.Action = Next
+		tag, next, _, err := calver(opts, now)
+		require.NoError(t, err)
+		require.Empty(t, tag)
+		require.Equal(t, "2026.03.0", next)
+	})
+
+	t.Run("current", func(t *testing.T) {
+		repo.Tag("v2026.03.0")
+		opts := opts
+		opts.Action = Current
+		tag, current, _, err := calver(opts, now)
+		require.NoError(t, err)
+		require.Equal(t, "v2026.03.0", tag)
+		require.Equal(t, "2026.03.0", current)
+	})
+}
@@ -0,0 +1,79 @@
+package svu
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/caarlos0/svu/v3/internal/conventional"
+	"github.com/caarlos0/svu/v3/internal/git"
+)
+
+// changelogSections are the changelog sections, in the order they are
+// rendered.
+var changelogSections = []string{
+	"Breaking Changes",
+	"Features",
+	"Bug Fixes",
+	"Other",
+}
+
+// Changelog returns the Markdown release notes of the next version, built
+// from the same commits used to compute it.
+func Changelog(opts Options) (string, error) {
+	tag, version, commits, err := nextTagWithLog(opts)
+	if err != nil {
+		return "", err
+	}
+	if commits == nil {
+		commits, err = repository(opts).Changelog(opts.Ctx, tag, opts.Directories)
+		if err != nil {
+			return "", fmt.Errorf("failed to get changelog: %w", err)
+		}
+	}
+	return renderChangelog(opts.Prefix+version, commits), nil
+}
+
+// changelogSection returns the section of the given commit, from its
+// conventional commit type.
+func changelogSection(commit git.Commit) string {
+	cc, err := conventional.Parse(commit.Title, commit.Body)
+	if err != nil {
+		return "Other"
+	}
+	if cc.Breaking {
+		return "Breaking Changes"
+	}
+	switch strings.ToLower(cc.Type) {
+	case "feat":
+		return "Features"
+	case "fix":
+		return "Bug Fixes"
+	default:
+		return "Other"
+	}
+}
+
+func renderChangelog(version string, commits []git.Commit) string {
+	groups := map[string][]git.Commit{}
+	for _, commit := range commits {
+		section := changelogSection(commit)
+		groups[section] = append(groups[section], commit)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## %s\n", version)
+	if len(commits) == 0 {
+		sb.WriteString("\nNo changes.\n")
+		return sb.String()
+	}
+	for _, section := range changelogSections {
+		if len(groups[section]) == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n### %s\n\n", section)
+		for _, commit := range groups[section] {
+			fmt.Fprintf(&sb, "- %s (%s)\n", commit.Title, shortSHA(commit.SHA))
+		}
+	}
+	return sb.String()
+}
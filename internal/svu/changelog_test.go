@@ -0,0 +1,115 @@
+package svu
+
+import (
+	"context"
+	"testing"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+	"github.com/caarlos0/svu/v3/pkg/svu/svutest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderChangelog(t *testing.T) {
+	t.Run("grouped", func(t *testing.T) {
+		require.Equal(t, `## v2.0.0
+
+### Breaking Changes
+
+- feat!: remove foo (1111111)
+- chore: bar (2222222)
+
+### Features
+
+- feat(api): add baz (3333333)
+
+### Bug Fixes
+
+- fix: qux (4444444)
+- Fix(ui): quux (5555555)
+
+### Other
+
+- docs: update readme (6666666)
+- Merge branch 'main' (7777777)
+`, renderChangelog("v2.0.0", []git.Commit{
+			{SHA: "1111111aaaa", Title: "feat!: remove foo"},
+			{SHA: "2222222aaaa", Title: "chore: bar", Body: "BREAKING CHANGE: bar is gone"},
+			{SHA: "3333333aaaa", Title: "feat(api): add baz"},
+			{SHA: "4444444aaaa", Title: "fix: qux"},
+			{SHA: "5555555aaaa", Title: "Fix(ui): quux"},
+			{SHA: "6666666aaaa", Title: "docs: update readme"},
+			{SHA: "7777777aaaa", Title: "Merge branch 'main'"},
+		}))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		require.Equal(t, "## v1.0.0\n\nNo changes.\n", renderChangelog("v1.0.0", nil))
+	})
+}
+
+// changelogCounter counts how many times the history is read.
+type changelogCounter struct {
+	git.Repository
+	count int
+}
+
+func (r *changelogCounter) Changelog(ctx context.Context, tag string, dirs []string) ([]git.Commit, error) {
+	r.count++
+	return r.Repository.Changelog(ctx, tag, dirs)
+}
+
+func TestChangelog(t *testing.T) {
+	repo := svutest.New()
+	repo.Commit("chore: init")
+	repo.Tag("v1.2.3")
+	repo.Commit("perf: faster")
+	repo.Commit("fix: typo")
+	repo.Commit("feat(api)!: remove foo")
+
+	counter := &changelogCounter{Repository: repo}
+	opts := Options{
+		Ctx:        t.Context(),
+		Action:     Next,
+		Prefix:     "v",
+		TagMode:    git.TagModeAll,
+		Repository: counter,
+		// the rules only decide the version, not the sections.
+		Rules: []Rule{
+			{Types: []string{"perf"}, Bump: "patch"},
+			{Types: []string{"fix"}, Bump: "none"},
+		},
+	}
+
+	changelog, err := Changelog(opts)
+	require.NoError(t, err)
+	require.Equal(t, `## v1.2.4
+
+### Breaking Changes
+
+- feat(api)!: remove foo (0000000)
+
+### Bug Fixes
+
+- fix: typo (0000000)
+
+### Other
+
+- perf: faster (0000000)
+`, changelog)
+	require.Equal(t, 1, counter.count, "should read the history once")
+
+	t.Run("calver", func(t *testing.T) {
+		repo := svutest.New()
+		repo.Commit("chore: init")
+		repo.Tag("v2020.01.0")
+		repo.Commit("perf: faster")
+		counter := &changelogCounter{Repository: repo}
+		opts := opts
+		opts.Scheme = SchemeCalver
+		opts.Repository = counter
+		changelog, err := Changelog(opts)
+		require.NoError(t, err)
+		require.Contains(t, changelog, "- perf: faster (0000000)")
+		require.Equal(t, 1, counter.count, "should read the history once")
+	})
+}
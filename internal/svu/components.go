@@ -0,0 +1,337 @@
+package svu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Component is a part of a monorepo that is versioned independently.
+type Component struct {
+	Name string `mapstructure:"name"`
+	// Module is the directory of the Go module of this component, relative
+	// to the repository root, if any. Only components with a module have
+	// their major version bumps checked against the module path, and their
+	// exported API changes detected.
+	Module string       `mapstructure:"module"`
+	Tag    ComponentTag `mapstructure:"tag"`
+	Log    ComponentLog `mapstructure:"log"`
+	// DependsOn are the names of the components, or the paths, this
+	// component depends on.
+	DependsOn []string `mapstructure:"depends_on"`
+	// Propagate is the minimum bump this component gets when any of its
+	// dependencies change. Defaults to patch.
+	Propagate string `mapstructure:"propagate"`
+}
+
+// ComponentTag is the tag configuration of a component.
+type ComponentTag struct {
+	// Prefix defaults to the component name followed by "/v".
+	Prefix string `mapstructure:"prefix"`
+	// Pattern defaults to the prefix followed by "*".
+	Pattern string `mapstructure:"pattern"`
+}
+
+// ComponentLog is the log configuration of a component.
+type ComponentLog struct {
+	Directory []string `mapstructure:"directory"`
+}
+
+// Options returns the given options with the component tag prefix, tag
+// pattern and log directories set.
+func (c Component) Options(opts Options) Options {
+	opts.Prefix = c.Tag.Prefix
+	if opts.Prefix == "" {
+		opts.Prefix = c.Name + "/v"
+	}
+	opts.Pattern = c.Tag.Pattern
+	if opts.Pattern == "" {
+		opts.Pattern = opts.Prefix + "*"
+	}
+	opts.Directories = c.Log.Directory
+	opts.GoModule = c.Module
+	if c.Module == "" {
+		opts.GoMajorCheck = GoMajorCheckOff
+		opts.DetectGoAPI = false
+	}
+	return opts
+}
+
+// FindComponent returns the component with the given name.
+func FindComponent(components []Component, name string) (Component, error) {
+	for _, c := range components {
+		if c.Name == name {
+			return c, nil
+		}
+	}
+	return Component{}, fmt.Errorf("component %q not found", name)
+}
+
+// componentResult is the version computed for a component.
+type componentResult struct {
+	component Component
+	opts      Options
+	tag       string
+	version   string
+	// explanation is only set when the version was computed from the git
+	// history, which is when dependencies are taken into account.
+	explanation *Explanation
+}
+
+// Components returns the version of every given component, one per line,
+// prefixed by the component name.
+//
+// When computing the next version, components that depend on components or
+// paths that changed get at least their Propagate bump.
+//
+// If JSON is set, it returns a JSON array instead, and if Format is set, each
+// line is formatted with it.
+// If Explain is set, it returns the explanation of every component instead.
+func Components(opts Options, components []Component) (string, error) {
+	if len(components) == 0 {
+		return "", errors.New("no components configured")
+	}
+
+	results, err := computeComponents(opts, components)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.Explain {
+		return explainComponents(opts, results)
+	}
+
+	var lines []string
+	var infos []VersionInfo
+	for _, r := range results {
+		if !opts.JSON && opts.Format == "" {
+			lines = append(lines, r.component.Name+" "+r.opts.Prefix+r.version)
+			continue
+		}
+
+		info := versionInfo(r.version, r.tag, r.opts)
+		info.Component = r.component.Name
+		if opts.JSON {
+			infos = append(infos, info)
+			continue
+		}
+		line, err := formatOutput(info, r.opts)
+		if err != nil {
+			return "", fmt.Errorf("component %s: %w", r.component.Name, err)
+		}
+		lines = append(lines, line)
+	}
+
+	if opts.JSON {
+		b, err := json.Marshal(infos)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert versions to json: %w", err)
+		}
+		return string(b), nil
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func computeComponents(opts Options, components []Component) ([]*componentResult, error) {
+	fromHistory := (opts.Action == Next || opts.Action == PreRelease) && opts.Scheme != SchemeCalver
+	if opts.Explain && !fromHistory {
+		return nil, errors.New("explain is only supported when computing the next semantic version")
+	}
+
+	results := make([]*componentResult, 0, len(components))
+	byName := map[string]*componentResult{}
+	for _, c := range components {
+		r := &componentResult{
+			component: c,
+			opts:      c.Options(opts),
+		}
+		if fromHistory {
+			ex, err := Explain(r.opts)
+			if err != nil {
+				return nil, fmt.Errorf("component %s: %w", c.Name, err)
+			}
+			ex.Component = c.Name
+			r.tag = ex.Tag
+			r.version = strings.TrimPrefix(ex.Version, r.opts.Prefix)
+			r.explanation = &ex
+		} else {
+			tag, version, err := nextTag(r.opts)
+			if err != nil {
+				return nil, fmt.Errorf("component %s: %w", c.Name, err)
+			}
+			r.tag = tag
+			r.version = version
+		}
+		results = append(results, r)
+		byName[c.Name] = r
+	}
+
+	if fromHistory {
+		if err := propagate(components, byName); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// propagate bumps the components whose dependencies changed, in dependency
+// order, so a change is propagated through the whole dependency chain.
+func propagate(components []Component, results map[string]*componentResult) error {
+	order, err := sortComponents(components)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range order {
+		r := results[c.Name]
+		level := BumpPatch
+		if c.Propagate != "" {
+			level, err = ParseBump(c.Propagate)
+			if err != nil {
+				return fmt.Errorf("component %s: %w", c.Name, err)
+			}
+		}
+		if level == BumpNone || level <= r.explanation.Bump {
+			continue
+		}
+
+		chain, err := changedDependency(c, r, results)
+		if err != nil {
+			return err
+		}
+		if chain == nil {
+			continue
+		}
+		chain = append(chain, c.Name)
+
+		current, err := semver.NewVersion(r.explanation.Current)
+		if err != nil {
+			return fmt.Errorf("component %s: %w", c.Name, err)
+		}
+		bump := level
+		if bump == BumpMajor && current.Major() == 0 && r.opts.KeepV0 {
+			bump = BumpMinor
+		}
+		decision := fmt.Sprintf("dependency changed, propagated %s change: %s", bump, strings.Join(chain, " -> "))
+		capped, err := capBump(bump, r.opts)
+		if err != nil {
+			return fmt.Errorf("component %s: %w", c.Name, err)
+		}
+		if capped != bump {
+			bump = capped
+			decision += fmt.Sprintf(", capped to %s by the branch max_bump", capped)
+		}
+		if bump <= r.explanation.Bump {
+			continue
+		}
+		next, err := setPreReleaseAndMetadata(current, incVersion(current, bump), r.tag, r.opts)
+		if err != nil {
+			return fmt.Errorf("component %s: %w", c.Name, err)
+		}
+		if err := checkGoMajor(r.opts, current, next); err != nil {
+			return fmt.Errorf("component %s: %w", c.Name, err)
+		}
+
+		r.version = next.String()
+		r.explanation.Version = r.opts.Prefix + r.version
+		r.explanation.Bump = bump
+		r.explanation.Decision = decision
+		r.explanation.Propagation = chain
+	}
+	return nil
+}
+
+// changedDependency returns the propagation chain of the first dependency of
+// the given component that changed, or nil if none did.
+func changedDependency(c Component, r *componentResult, results map[string]*componentResult) ([]string, error) {
+	for _, dep := range c.DependsOn {
+		if d, ok := results[dep]; ok {
+			if d.explanation.Bump == BumpNone {
+				continue
+			}
+			if len(d.explanation.Propagation) > 0 {
+				return slices.Clone(d.explanation.Propagation), nil
+			}
+			return []string{dep}, nil
+		}
+
+		// not a component, so it is a path.
+		changes, err := repository(r.opts).Changelog(r.opts.Ctx, r.tag, []string{dep})
+		if err != nil {
+			return nil, fmt.Errorf("component %s: failed to get changelog of %s: %w", c.Name, dep, err)
+		}
+		if len(changes) > 0 {
+			return []string{dep}, nil
+		}
+	}
+	return nil, nil
+}
+
+// sortComponents returns the components sorted so every component comes
+// after the components it depends on.
+func sortComponents(components []Component) ([]Component, error) {
+	byName := map[string]Component{}
+	for _, c := range components {
+		byName[c.Name] = c
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	result := make([]Component, 0, len(components))
+	var visit func(c Component, path []string) error
+	visit = func(c Component, path []string) error {
+		path = append(path, c.Name)
+		switch state[c.Name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle between components: %s", strings.Join(path, " -> "))
+		case visited:
+			return nil
+		}
+		state[c.Name] = visiting
+		for _, dep := range c.DependsOn {
+			if d, ok := byName[dep]; ok {
+				if err := visit(d, path); err != nil {
+					return err
+				}
+			}
+		}
+		state[c.Name] = visited
+		result = append(result, c)
+		return nil
+	}
+
+	for _, c := range components {
+		if err := visit(c, nil); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func explainComponents(opts Options, results []*componentResult) (string, error) {
+	explanations := make([]Explanation, 0, len(results))
+	for _, r := range results {
+		explanations = append(explanations, *r.explanation)
+	}
+
+	if opts.JSON {
+		b, err := json.Marshal(explanations)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert explanations to json: %w", err)
+		}
+		return string(b), nil
+	}
+
+	out := make([]string, 0, len(explanations))
+	for _, ex := range explanations {
+		out = append(out, ex.String())
+	}
+	return strings.Join(out, "\n\n"), nil
+}
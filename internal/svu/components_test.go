@@ -0,0 +1,182 @@
+package svu
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponentOptions(t *testing.T) {
+	base := Options{
+		Prefix:      "v",
+		Pattern:     "v*",
+		Directories: []string{"."},
+		Always:      true,
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		opts := Component{Name: "api"}.Options(base)
+		require.Equal(t, "api/v", opts.Prefix)
+		require.Equal(t, "api/v*", opts.Pattern)
+		require.Empty(t, opts.Directories)
+		require.True(t, opts.Always)
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		opts := Component{
+			Name: "web",
+			Tag:  ComponentTag{Prefix: "web-v", Pattern: "web-v1.*"},
+			Log:  ComponentLog{Directory: []string{"web", "shared"}},
+		}.Options(base)
+		require.Equal(t, "web-v", opts.Prefix)
+		require.Equal(t, "web-v1.*", opts.Pattern)
+		require.Equal(t, []string{"web", "shared"}, opts.Directories)
+	})
+
+	t.Run("go module", func(t *testing.T) {
+		opts := Component{Name: "api"}.Options(Options{GoMajorCheck: GoMajorCheckError})
+		require.Equal(t, GoMajorCheckOff, opts.GoMajorCheck)
+
+		opts = Component{Name: "api", Module: "api"}.Options(Options{GoMajorCheck: GoMajorCheckError})
+		require.Equal(t, GoMajorCheckError, opts.GoMajorCheck)
+		require.Equal(t, "api", opts.GoModule)
+	})
+}
+
+func TestFindComponent(t *testing.T) {
+	components := []Component{{Name: "api"}, {Name: "web"}}
+
+	c, err := FindComponent(components, "web")
+	require.NoError(t, err)
+	require.Equal(t, "web", c.Name)
+
+	_, err = FindComponent(components, "nope")
+	require.Error(t, err)
+}
+
+func TestComponentsEmpty(t *testing.T) {
+	_, err := Components(Options{Ctx: t.Context()}, nil)
+	require.Error(t, err)
+}
+
+func TestSortComponents(t *testing.T) {
+	t.Run("dependencies first", func(t *testing.T) {
+		sorted, err := sortComponents([]Component{
+			{Name: "web", DependsOn: []string{"api", "some/path"}},
+			{Name: "api", DependsOn: []string{"lib"}},
+			{Name: "lib"},
+		})
+		require.NoError(t, err)
+		var names []string
+		for _, c := range sorted {
+			names = append(names, c.Name)
+		}
+		require.Equal(t, []string{"lib", "api", "web"}, names)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		_, err := sortComponents([]Component{
+			{Name: "a", DependsOn: []string{"b"}},
+			{Name: "b", DependsOn: []string{"c"}},
+			{Name: "c", DependsOn: []string{"a"}},
+		})
+		require.EqualError(t, err, "dependency cycle between components: a -> b -> c -> a")
+	})
+}
+
+func TestPropagate(t *testing.T) {
+	components := []Component{
+		{Name: "lib"},
+		{Name: "api", DependsOn: []string{"lib"}},
+		{Name: "web", DependsOn: []string{"api"}, Propagate: "minor"},
+		{Name: "docs", DependsOn: []string{"web"}, Propagate: "none"},
+		{Name: "cli", DependsOn: []string{"lib"}},
+	}
+	result := func(c Component, current string, bump Bump) *componentResult {
+		opts := c.Options(Options{Ctx: t.Context()})
+		return &componentResult{
+			component: c,
+			opts:      opts,
+			version:   current,
+			explanation: &Explanation{
+				Component: c.Name,
+				Current:   current,
+				Bump:      bump,
+				Version:   opts.Prefix + current,
+			},
+		}
+	}
+	results := map[string]*componentResult{
+		"lib":  result(components[0], "1.1.0", BumpMinor),
+		"api":  result(components[1], "2.0.0", BumpNone),
+		"web":  result(components[2], "0.3.1", BumpPatch),
+		"docs": result(components[3], "1.0.0", BumpNone),
+		"cli":  result(components[4], "1.0.0", BumpMajor),
+	}
+	require.NoError(t, propagate(components, results))
+
+	for name, expected := range map[string]struct {
+		version string
+		chain   []string
+	}{
+		"lib":  {"1.1.0", nil},
+		"api":  {"2.0.1", []string{"lib", "api"}},
+		"web":  {"0.4.0", []string{"lib", "api", "web"}},
+		"docs": {"1.0.0", nil},
+		"cli":  {"1.0.0", nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, expected.version, results[name].version)
+			require.Equal(t, expected.chain, results[name].explanation.Propagation)
+		})
+	}
+}
+
+func TestPropagateMaxBump(t *testing.T) {
+	components := []Component{
+		{Name: "lib"},
+		{Name: "web", DependsOn: []string{"lib"}, Propagate: "minor"},
+	}
+	results := func(opts Options) map[string]*componentResult {
+		result := map[string]*componentResult{}
+		for _, c := range components {
+			o := c.Options(opts)
+			result[c.Name] = &componentResult{
+				component: c,
+				opts:      o,
+				version:   "1.2.3",
+				explanation: &Explanation{
+					Component: c.Name,
+					Current:   "1.2.3",
+					Version:   o.Prefix + "1.2.3",
+				},
+			}
+		}
+		result["lib"].version = "1.2.4"
+		result["lib"].explanation.Bump = BumpPatch
+		return result
+	}
+
+	t.Run("error", func(t *testing.T) {
+		err := propagate(components, results(Options{Ctx: t.Context(), MaxBump: "patch"}))
+		require.EqualError(t, err, "component web: found minor change, but the max_bump of the branch is patch: set on_exceed to warn to cap the bump instead")
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		var stderr bytes.Buffer
+		r := results(Options{Ctx: t.Context(), MaxBump: "patch", OnExceed: ExceedWarn, Stderr: &stderr})
+		require.NoError(t, propagate(components, r))
+		require.Equal(t, "1.2.4", r["web"].version)
+		require.Equal(t, BumpPatch, r["web"].explanation.Bump)
+		require.Equal(t, "dependency changed, propagated minor change: lib -> web, capped to patch by the branch max_bump", r["web"].explanation.Decision)
+		require.Contains(t, stderr.String(), "capping the bump")
+	})
+
+	t.Run("capped to none", func(t *testing.T) {
+		r := results(Options{Ctx: t.Context(), MaxBump: "none", OnExceed: ExceedWarn, Stderr: &bytes.Buffer{}})
+		require.NoError(t, propagate(components, r))
+		require.Equal(t, "1.2.3", r["web"].version)
+		require.Empty(t, r["web"].explanation.Propagation)
+	})
+}
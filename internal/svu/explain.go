@@ -0,0 +1,132 @@
+package svu
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+)
+
+// Explanation describes how the next version was computed.
+type Explanation struct {
+	Component string           `json:"component,omitempty"`
+	Tag       string           `json:"tag"`
+	Current   string           `json:"current"`
+	Range     string           `json:"range"`
+	Filters   Filters          `json:"filters"`
+	Commits   []Classification `json:"commits"`
+	Bump      Bump             `json:"bump"`
+	Decision  string           `json:"decision"`
+	Version   string           `json:"version"`
+	// API are the changes to the exported Go API, if detect.go_api is set.
+	API []APIChange `json:"api,omitempty"`
+	// Propagation is the chain of components or paths a dependency change
+	// was propagated through, ending with this component.
+	Propagation []string `json:"propagation,omitempty"`
+
+	// log are the commits the version was computed from.
+	log []git.Commit
+}
+
+// Filters are the options that restrict or change the version computation.
+type Filters struct {
+	TagMode     string   `json:"tag_mode"`
+	Pattern     string   `json:"pattern,omitempty"`
+	Directories []string `json:"directories,omitempty"`
+	KeepV0      bool     `json:"v0"`
+	Always      bool     `json:"always"`
+	DetectGoAPI bool     `json:"detect_go_api,omitempty"`
+	MaxBump     string   `json:"max_bump,omitempty"`
+}
+
+// Classification is the bump a commit asks for, and the rule that decided it.
+type Classification struct {
+	SHA   string `json:"sha"`
+	Title string `json:"title"`
+	Bump  Bump   `json:"bump"`
+	Rule  string `json:"rule,omitempty"`
+}
+
+// Explain computes the next version the same way Version does for the Next
+// and PreRelease actions, recording every step taken.
+func Explain(opts Options) (Explanation, error) {
+	ex, _, err := compute(opts)
+	return ex, err
+}
+
+// JSON returns the explanation as JSON.
+func (e Explanation) JSON() (string, error) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert explanation to json: %w", err)
+	}
+	return string(b), nil
+}
+
+func (e Explanation) String() string {
+	var sb strings.Builder
+	if e.Component != "" {
+		fmt.Fprintf(&sb, "component: %s\n", e.Component)
+	}
+	tag := e.Tag
+	if tag == "" {
+		tag = "(none)"
+	}
+	fmt.Fprintf(&sb, "tag:      %s\n", tag)
+	fmt.Fprintf(&sb, "current:  %s\n", e.Current)
+	fmt.Fprintf(&sb, "range:    %s\n", e.Range)
+	fmt.Fprintf(&sb, "filters:  tag.mode=%s", e.Filters.TagMode)
+	if e.Filters.Pattern != "" {
+		fmt.Fprintf(&sb, " tag.pattern=%s", e.Filters.Pattern)
+	}
+	if len(e.Filters.Directories) > 0 {
+		fmt.Fprintf(&sb, " log.directory=%s", strings.Join(e.Filters.Directories, ","))
+	}
+	if e.Filters.DetectGoAPI {
+		sb.WriteString(" detect.go_api=true")
+	}
+	if e.Filters.MaxBump != "" {
+		fmt.Fprintf(&sb, " max_bump=%s", e.Filters.MaxBump)
+	}
+	fmt.Fprintf(&sb, " v0=%t always=%t\n", e.Filters.KeepV0, e.Filters.Always)
+
+	fmt.Fprintf(&sb, "commits:  %d\n", len(e.Commits))
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	for _, c := range e.Commits {
+		rule := c.Rule
+		if rule == "" {
+			rule = "-"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", shortSHA(c.SHA), c.Bump, rule, c.Title)
+	}
+	_ = w.Flush()
+
+	if len(e.API) > 0 {
+		fmt.Fprintf(&sb, "api:      %d changes\n", len(e.API))
+		w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		for _, c := range e.API {
+			desc := c.After
+			if c.Change == "removed" {
+				desc = c.Before
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", c.Change, c.Symbol, desc)
+		}
+		_ = w.Flush()
+	}
+
+	if len(e.Propagation) > 0 {
+		fmt.Fprintf(&sb, "propagation: %s\n", strings.Join(e.Propagation, " -> "))
+	}
+	fmt.Fprintf(&sb, "decision: %s\n", e.Decision)
+	fmt.Fprintf(&sb, "version:  %s", e.Version)
+	return sb.String()
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,135 @@
+package svu
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/caarlos0/svu/v3/internal/git"
+	"github.com/caarlos0/svu/v3/pkg/svu/svutest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecide(t *testing.T) {
+	commits := classifyAll([]git.Commit{
+		{SHA: "3333333", Title: "chore: foo"},
+		{SHA: "2222222", Title: "feat: bar"},
+		{SHA: "1111111", Title: "feat!: baz"},
+	}, defaultRules)
+	require.Equal(t, []Classification{
+		{SHA: "3333333", Title: "chore: foo", Bump: BumpNone},
+		{SHA: "2222222", Title: "feat: bar", Bump: BumpMinor, Rule: "feature"},
+		{SHA: "1111111", Title: "feat!: baz", Bump: BumpMajor, Rule: "breaking"},
+	}, commits)
+
+	t.Run("major", func(t *testing.T) {
+		next, bump, reason, err := decide(semver.MustParse("1.2.3"), commits, Options{})
+		require.NoError(t, err)
+		require.Equal(t, "2.0.0", next.String())
+		require.Equal(t, BumpMajor, bump)
+		require.Equal(t, "found major change: 1111111 feat!: baz (rule breaking)", reason)
+	})
+
+	t.Run("keep v0", func(t *testing.T) {
+		next, bump, reason, err := decide(semver.MustParse("0.2.3"), commits, Options{KeepV0: true})
+		require.NoError(t, err)
+		require.Equal(t, "0.3.0", next.String())
+		require.Equal(t, BumpMinor, bump)
+		require.Contains(t, reason, "'keep v0' is set")
+	})
+
+	t.Run("always", func(t *testing.T) {
+		next, bump, reason, err := decide(semver.MustParse("1.2.3"), commits[:1], Options{Always: true})
+		require.NoError(t, err)
+		require.Equal(t, "1.2.4", next.String())
+		require.Equal(t, BumpPatch, bump)
+		require.Equal(t, "found no changes, but 'always' is set", reason)
+	})
+
+	t.Run("without sha", func(t *testing.T) {
+		api := Classification{Title: "exported go api changed: 0 added, 1 removed, 0 changed", Bump: BumpMajor, Rule: goAPIRule}
+		next, bump, reason, err := decide(semver.MustParse("1.2.3"), append(commits[:2:2], api), Options{})
+		require.NoError(t, err)
+		require.Equal(t, "2.0.0", next.String())
+		require.Equal(t, BumpMajor, bump)
+		require.Equal(t, "found major change: exported go api changed: 0 added, 1 removed, 0 changed (rule detect.go_api)", reason)
+	})
+}
+
+func TestExplanationOutput(t *testing.T) {
+	ex := Explanation{
+		Tag:     "v1.2.3",
+		Current: "1.2.3",
+		Range:   "tags/v1.2.3..HEAD",
+		Filters: Filters{
+			TagMode:     git.TagModeAll,
+			Directories: []string{"foo", "bar"},
+		},
+		Commits: []Classification{
+			{SHA: "2222222aaaa", Title: "feat: bar", Bump: BumpMinor, Rule: "feature"},
+			{SHA: "3333333bbbb", Title: "chore: foo"},
+		},
+		Bump:     BumpMinor,
+		Decision: "found minor change: 2222222aaaa feat: bar (rule feature)",
+		Version:  "v1.3.0",
+	}
+
+	require.Equal(t, `tag:      v1.2.3
+current:  1.2.3
+range:    tags/v1.2.3..HEAD
+filters:  tag.mode=all log.directory=foo,bar v0=false always=false
+commits:  2
+  2222222  minor  feature  feat: bar
+  3333333  none   -        chore: foo
+decision: found minor change: 2222222aaaa feat: bar (rule feature)
+version:  v1.3.0`, ex.String())
+
+	out, err := ex.JSON()
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"tag": "v1.2.3",
+		"current": "1.2.3",
+		"range": "tags/v1.2.3..HEAD",
+		"filters": {"tag_mode": "all", "directories": ["foo", "bar"], "v0": false, "always": false},
+		"commits": [
+			{"sha": "2222222aaaa", "title": "feat: bar", "bump": "minor", "rule": "feature"},
+			{"sha": "3333333bbbb", "title": "chore: foo", "bump": "none"}
+		],
+		"bump": "minor",
+		"decision": "found minor change: 2222222aaaa feat: bar (rule feature)",
+		"version": "v1.3.0"
+	}`, out)
+}
+
+func TestExplain(t *testing.T) {
+	repo := svutest.New()
+	repo.Commit("chore: init")
+	repo.Tag("v1.2.3")
+	sha := repo.Commit("feat: foo")
+	repo.Commit("chore: bar")
+	opts := Options{
+		Ctx:        t.Context(),
+		Action:     PreRelease,
+		Prefix:     "v",
+		PreRelease: "beta",
+		TagMode:    git.TagModeAll,
+		Repository: repo,
+	}
+
+	ex, err := Explain(opts)
+	require.NoError(t, err)
+	require.Equal(t, "v1.2.3", ex.Tag)
+	require.Equal(t, "1.2.3", ex.Current)
+	require.Len(t, ex.Commits, 2)
+	require.Equal(t, BumpMinor, ex.Bump)
+	require.Equal(t, "found minor change: "+sha+" feat: foo (rule feature)", ex.Decision)
+	require.Equal(t, "v1.3.0-beta.0", ex.Version)
+
+	version, err := Version(opts)
+	require.NoError(t, err)
+	require.Equal(t, ex.Version, version)
+
+	opts.MaxBump = "patch"
+	ex, err = Explain(opts)
+	require.ErrorContains(t, err, "found minor change, but the max_bump of the branch is patch")
+	require.Equal(t, BumpMinor, ex.Bump)
+}
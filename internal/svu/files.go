@@ -0,0 +1,373 @@
+package svu
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// File is a project file holding the version, e.g. a package.json.
+// At most one of JSON, YAML, Regex and Go can be set: if none is, the whole
+// file is the version.
+type File struct {
+	// Path is the path of the file, relative to the repository root.
+	Path string `mapstructure:"path"`
+	// JSON is the path of the version in a JSON file, with keys separated by
+	// dots, e.g. "version".
+	JSON string `mapstructure:"json"`
+	// YAML is the path of the version in a YAML file, with keys separated by
+	// dots, e.g. "image.tag".
+	YAML string `mapstructure:"yaml"`
+	// Regex is a regular expression whose first capture group is the
+	// version, e.g. `svu@v(\S+)`. Every match is rewritten.
+	Regex string `mapstructure:"regex"`
+	// Go is the name of a Go string constant, or variable, holding the
+	// version.
+	Go string `mapstructure:"go"`
+	// Prefix writes the version with the tag prefix, e.g. v1.2.3 instead of
+	// 1.2.3.
+	Prefix bool `mapstructure:"prefix"`
+}
+
+// WriteFiles computes the version from the given options, and writes it in
+// every given file, returning it.
+func WriteFiles(opts Options, files []File) (string, error) {
+	version, err := fileVersion(opts)
+	if err != nil {
+		return "", err
+	}
+	root, err := filesRoot(opts)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := writeFiles(root, files, opts.Prefix, version); err != nil {
+		return "", err
+	}
+	return opts.Prefix + version, nil
+}
+
+// CheckFiles returns the version computed from the given options, usually
+// the current one, and fails if any of the given files holds another one.
+func CheckFiles(opts Options, files []File) (string, error) {
+	version, err := fileVersion(opts)
+	if err != nil {
+		return "", err
+	}
+	root, err := filesRoot(opts)
+	if err != nil {
+		return "", err
+	}
+
+	var outdated []string
+	for _, f := range files {
+		value := f.value(opts.Prefix, version)
+		content, spans, err := f.locate(root)
+		if err != nil {
+			return "", err
+		}
+		for _, span := range spans {
+			if got := string(content[span[0]:span[1]]); got != value {
+				outdated = append(outdated, fmt.Sprintf("%s has %s", f.Path, got))
+				break
+			}
+		}
+	}
+	if len(outdated) > 0 {
+		return "", fmt.Errorf(
+			"files out of sync with %s%s: %s",
+			opts.Prefix,
+			version,
+			strings.Join(outdated, ", "),
+		)
+	}
+	return opts.Prefix + version, nil
+}
+
+// fileVersion returns the version computed from the given options, without
+// its prefix.
+func fileVersion(opts Options) (string, error) {
+	opts.JSON = false
+	opts.Format = ""
+	opts.Explain = false
+	version, err := Version(opts)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(version, opts.Prefix), nil
+}
+
+func filesRoot(opts Options) (string, error) {
+	root := repository(opts).Root(opts.Ctx)
+	if root == "" {
+		return "", errors.New("could not find the repository root")
+	}
+	return root, nil
+}
+
+// writeFiles writes the given version in the given files, and returns the
+// previous content of the ones it changed, even if it fails.
+func writeFiles(root string, files []File, prefix, version string) (map[string][]byte, error) {
+	written := map[string][]byte{}
+	for _, f := range files {
+		value := f.value(prefix, version)
+		content, spans, err := f.locate(root)
+		if err != nil {
+			return written, err
+		}
+		updated := slices.Clone(content)
+		for _, span := range slices.Backward(spans) {
+			updated = slices.Concat(updated[:span[0]], []byte(value), updated[span[1]:])
+		}
+		if bytes.Equal(content, updated) {
+			log.Printf("%s is up to date", f.Path)
+			continue
+		}
+		if _, ok := written[f.Path]; !ok {
+			written[f.Path] = content
+		}
+		if err := writeFile(filepath.Join(root, f.Path), updated); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+		log.Printf("wrote %s to %s", value, f.Path)
+	}
+	return written, nil
+}
+
+// writeFile writes the given content to the given existing file, keeping its
+// permissions.
+func writeFile(p string, content []byte) error {
+	info, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, content, info.Mode().Perm())
+}
+
+// value returns the version as written in the file.
+func (f File) value(prefix, version string) string {
+	if f.Prefix {
+		return prefix + version
+	}
+	return version
+}
+
+// locate returns the content of the file, and the byte ranges of the
+// versions in it.
+func (f File) locate(root string) ([]byte, [][2]int, error) {
+	content, err := os.ReadFile(filepath.Join(root, f.Path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", f.Path, err)
+	}
+
+	var set int
+	for _, s := range []string{f.JSON, f.YAML, f.Regex, f.Go} {
+		if s != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, nil, fmt.Errorf("invalid file %s: only one of json, yaml, regex and go can be set", f.Path)
+	}
+
+	var spans [][2]int
+	switch {
+	case f.JSON != "":
+		spans, err = jsonSpans(content, f.JSON)
+	case f.YAML != "":
+		spans, err = yamlSpans(content, f.YAML)
+	case f.Regex != "":
+		spans, err = regexSpans(content, f.Regex)
+	case f.Go != "":
+		spans, err = goSpans(content, f.Go)
+	default:
+		spans = [][2]int{{0, len(bytes.TrimRight(content, " \t\r\n"))}}
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not find the version in %s: %w", f.Path, err)
+	}
+	return content, spans, nil
+}
+
+// jsonSpans returns the range of the string at the given dot separated path,
+// without its quotes.
+func jsonSpans(content []byte, path string) ([][2]int, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if tok != json.Delim('{') {
+			return nil, fmt.Errorf("%s is not an object", strings.Join(keys[:i], "."))
+		}
+		found := false
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			if tok == key {
+				found = true
+				break
+			}
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, err
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s not found", strings.Join(keys[:i+1], "."))
+		}
+	}
+
+	start := int(dec.InputOffset())
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := tok.(string); !ok {
+		return nil, fmt.Errorf("%s is not a string", path)
+	}
+	end := int(dec.InputOffset())
+	start += bytes.IndexByte(content[start:end], '"') + 1
+	return [][2]int{{start, end - 1}}, nil
+}
+
+// yamlSpans returns the range of the scalar at the given dot separated path,
+// without its quotes.
+func yamlSpans(content []byte, path string) ([][2]int, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, errors.New("empty document")
+	}
+	node := doc.Content[0]
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		if node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s is not a mapping", strings.Join(keys[:i], "."))
+		}
+		var next *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == key {
+				next = node.Content[j+1]
+			}
+		}
+		if next == nil {
+			return nil, fmt.Errorf("%s not found", strings.Join(keys[:i+1], "."))
+		}
+		node = next
+	}
+	if node.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("%s is not a scalar", path)
+	}
+
+	start := lineOffset(content, node.Line, node.Column)
+	switch node.Style {
+	case 0:
+	case yaml.SingleQuotedStyle, yaml.DoubleQuotedStyle:
+		start++
+	default:
+		return nil, fmt.Errorf("%s must be a plain or quoted scalar", path)
+	}
+	end := start + len(node.Value)
+	if start < 0 || end > len(content) || string(content[start:end]) != node.Value {
+		return nil, fmt.Errorf("%s must not have escaped characters", path)
+	}
+	return [][2]int{{start, end}}, nil
+}
+
+// lineOffset returns the byte offset of the given 1-based line and column,
+// in characters, or -1 if it is out of the content.
+func lineOffset(content []byte, line, column int) int {
+	offset := 0
+	for range line - 1 {
+		i := bytes.IndexByte(content[offset:], '\n')
+		if i < 0 {
+			return -1
+		}
+		offset += i + 1
+	}
+	for range column - 1 {
+		if offset >= len(content) {
+			return -1
+		}
+		_, size := utf8.DecodeRune(content[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// regexSpans returns the ranges of the first capture group of every match of
+// the given expression.
+func regexSpans(content []byte, expr string) ([][2]int, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex: %w", err)
+	}
+	if re.NumSubexp() == 0 {
+		return nil, fmt.Errorf("regex %s has no capture group", expr)
+	}
+	var spans [][2]int
+	for _, match := range re.FindAllSubmatchIndex(content, -1) {
+		if match[2] >= 0 {
+			spans = append(spans, [2]int{match[2], match[3]})
+		}
+	}
+	if len(spans) == 0 {
+		return nil, fmt.Errorf("regex %s does not match", expr)
+	}
+	return spans, nil
+}
+
+// goSpans returns the range of the string literal assigned to the given
+// constant, or variable, without its quotes.
+func goSpans(content []byte, name string) ([][2]int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || (gen.Tok != token.CONST && gen.Tok != token.VAR) {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, ident := range vs.Names {
+				if ident.Name != name {
+					continue
+				}
+				if i >= len(vs.Values) {
+					return nil, fmt.Errorf("%s has no value", name)
+				}
+				lit, ok := vs.Values[i].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					return nil, fmt.Errorf("%s is not a string literal", name)
+				}
+				start := fset.Position(lit.Pos()).Offset + 1
+				end := fset.Position(lit.End()).Offset - 1
+				return [][2]int{{start, end}}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s not found", name)
+}
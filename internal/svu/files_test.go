@@ -0,0 +1,109 @@
+package svu
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+	"github.com/caarlos0/svu/v3/pkg/svu/svutest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiles(t *testing.T) {
+	const (
+		packageJSON = "{\n  \"name\": \"foo\",\n  \"deps\": {\"version\": \"9.9.9\"},\n  \"version\": \"1.2.0\",\n  \"scripts\": {}\n}\n"
+		chartYAML   = "name: foo\n# the app\nimage:\n  repository: foo\n  tag: \"v1.2.0\" # pinned\nversion: 1.2.0\n"
+		versionGo   = "package main\n\n// Version is the version.\nconst Version = \"1.2.0\"\n\nvar other = \"1.2.0\"\n"
+		readme      = "go install foo@v1.2.0\n\nor foo@v1.2.0.\n"
+		version     = "1.2.0\n"
+	)
+	files := []File{
+		{Path: "package.json", JSON: "version"},
+		{Path: "Chart.yaml", YAML: "image.tag", Prefix: true},
+		{Path: "Chart.yaml", YAML: "version"},
+		{Path: "version.go", Go: "Version"},
+		{Path: "README.md", Regex: `foo@v(\d+\.\d+\.\d+)`},
+		{Path: "VERSION"},
+	}
+	setup := func(t *testing.T) Options {
+		t.Helper()
+		dir := t.TempDir()
+		for name, content := range map[string]string{
+			"package.json": packageJSON,
+			"Chart.yaml":   chartYAML,
+			"version.go":   versionGo,
+			"README.md":    readme,
+			"VERSION":      version,
+		} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+		}
+		repo := svutest.New()
+		repo.Dir = dir
+		repo.Commit("chore: init")
+		repo.Tag("v1.2.0")
+		repo.Commit("feat: foo")
+		return Options{
+			Ctx:        t.Context(),
+			Action:     Next,
+			Prefix:     "v",
+			TagMode:    git.TagModeAll,
+			Repository: repo,
+		}
+	}
+	read := func(t *testing.T, opts Options, name string) string {
+		t.Helper()
+		bts, err := os.ReadFile(filepath.Join(opts.Repository.Root(opts.Ctx), name))
+		require.NoError(t, err)
+		return string(bts)
+	}
+
+	t.Run("check", func(t *testing.T) {
+		opts := setup(t)
+		opts.Action = Current
+		v, err := CheckFiles(opts, files)
+		require.NoError(t, err)
+		require.Equal(t, "v1.2.0", v)
+	})
+
+	t.Run("write", func(t *testing.T) {
+		opts := setup(t)
+		v, err := WriteFiles(opts, files)
+		require.NoError(t, err)
+		require.Equal(t, "v1.3.0", v)
+
+		require.Equal(t, "{\n  \"name\": \"foo\",\n  \"deps\": {\"version\": \"9.9.9\"},\n  \"version\": \"1.3.0\",\n  \"scripts\": {}\n}\n", read(t, opts, "package.json"))
+		require.Equal(t, "name: foo\n# the app\nimage:\n  repository: foo\n  tag: \"v1.3.0\" # pinned\nversion: 1.3.0\n", read(t, opts, "Chart.yaml"))
+		require.Equal(t, "package main\n\n// Version is the version.\nconst Version = \"1.3.0\"\n\nvar other = \"1.2.0\"\n", read(t, opts, "version.go"))
+		require.Equal(t, "go install foo@v1.3.0\n\nor foo@v1.3.0.\n", read(t, opts, "README.md"))
+		require.Equal(t, "1.3.0\n", read(t, opts, "VERSION"))
+
+		_, err = CheckFiles(opts, files)
+		require.NoError(t, err)
+
+		opts.Action = Current
+		_, err = CheckFiles(opts, files)
+		require.EqualError(t, err, "files out of sync with v1.2.0: package.json has 1.3.0, Chart.yaml has v1.3.0, Chart.yaml has 1.3.0, version.go has 1.3.0, README.md has 1.3.0, VERSION has 1.3.0")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		opts := setup(t)
+		for file, expected := range map[File]string{
+			{Path: "package.json", JSON: "foo.bar"}:  "could not find the version in package.json: foo not found",
+			{Path: "package.json", JSON: "name.bar"}: "could not find the version in package.json: name is not an object",
+			{Path: "package.json", JSON: "deps"}:     "could not find the version in package.json: deps is not a string",
+			{Path: "Chart.yaml", YAML: "image.foo"}:  "could not find the version in Chart.yaml: image.foo not found",
+			{Path: "Chart.yaml", YAML: "image"}:      "could not find the version in Chart.yaml: image is not a scalar",
+			{Path: "version.go", Go: "Foo"}:          "could not find the version in version.go: Foo not found",
+			{Path: "README.md", Regex: `foo@v\S+`}:   `could not find the version in README.md: regex foo@v\S+ has no capture group`,
+			{Path: "README.md", Regex: `bar@v(\S+)`}: `could not find the version in README.md: regex bar@v(\S+) does not match`,
+			{Path: "VERSION", JSON: "a", Go: "b"}:    "invalid file VERSION: only one of json, yaml, regex and go can be set",
+			{Path: "missing"}:                        "failed to read missing",
+		} {
+			t.Run(file.Path, func(t *testing.T) {
+				_, err := WriteFiles(opts, []File{file})
+				require.ErrorContains(t, err, expected)
+			})
+		}
+	})
+}
@@ -0,0 +1,406 @@
+package svu
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// APIChange is a change to an exported symbol of a Go module.
+type APIChange struct {
+	Symbol string `json:"symbol"`
+	// Change is either "added", "removed" or "changed".
+	Change string `json:"change"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// The rule name used for the bump caused by exported Go API changes.
+const goAPIRule = "detect.go_api"
+
+// goAPIChanges returns the changes to the exported API of the Go module in the
+// GoModule directory between the given tag and HEAD.
+//
+// Both are checked out in temporary worktrees and type-checked from source.
+// Imports from outside the module that cannot be resolved are replaced by
+// fake packages, so only changes to the names of their types are detected.
+func goAPIChanges(opts Options, tag string) ([]APIChange, error) {
+	if tag == "" {
+		return nil, nil
+	}
+
+	tmp, err := os.MkdirTemp("", "svu-go-api-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	// the standard library is the same for both, so it is only loaded once.
+	fset := token.NewFileSet()
+	std := importer.ForCompiler(fset, "source", nil)
+
+	apis := make([]map[string]string, 0, 2)
+	for _, ref := range []string{tag, "HEAD"} {
+		dir := filepath.Join(tmp, strings.ReplaceAll(ref, "/", "_"))
+		if err := repository(opts).AddWorktree(opts.Ctx, dir, ref); err != nil {
+			return nil, fmt.Errorf("failed to check out %s: %w", ref, err)
+		}
+		api, err := goAPI(filepath.Join(dir, filepath.FromSlash(opts.GoModule)), fset, std)
+		if rerr := repository(opts).RemoveWorktree(opts.Ctx, dir); rerr != nil {
+			log.Printf("failed to remove worktree %s: %v\n", dir, rerr)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get go api at %s: %w", ref, err)
+		}
+		apis = append(apis, api)
+	}
+	return diffAPI(apis[0], apis[1]), nil
+}
+
+// detectGoAPI returns the given commits with the bump caused by the changes
+// to the exported Go API since the given tag appended, if DetectGoAPI is set,
+// and the changes themselves.
+func detectGoAPI(opts Options, tag string, commits []Classification) ([]Classification, []APIChange, error) {
+	if !opts.DetectGoAPI {
+		return commits, nil, nil
+	}
+	changes, err := goAPIChanges(opts, tag)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to detect go api changes: %w", err)
+	}
+	if c, ok := apiClassification(changes); ok {
+		commits = append(slices.Clone(commits), c)
+	}
+	return commits, changes, nil
+}
+
+// apiClassification returns the bump the given API changes ask for: major if
+// anything was removed or changed, minor if anything was added.
+func apiClassification(changes []APIChange) (Classification, bool) {
+	counts := map[string]int{}
+	for _, c := range changes {
+		counts[c.Change]++
+	}
+	bump := BumpNone
+	switch {
+	case counts["removed"] > 0 || counts["changed"] > 0:
+		bump = BumpMajor
+	case counts["added"] > 0:
+		bump = BumpMinor
+	default:
+		return Classification{}, false
+	}
+	return Classification{
+		Title: fmt.Sprintf(
+			"exported go api changed: %d added, %d removed, %d changed",
+			counts["added"], counts["removed"], counts["changed"],
+		),
+		Bump: bump,
+		Rule: goAPIRule,
+	}, true
+}
+
+// diffAPI returns the changes between the given APIs, sorted by symbol.
+func diffAPI(before, after map[string]string) []APIChange {
+	var changes []APIChange
+	for symbol, b := range before {
+		a, ok := after[symbol]
+		switch {
+		case !ok:
+			changes = append(changes, APIChange{Symbol: symbol, Change: "removed", Before: b})
+		case a != b:
+			changes = append(changes, APIChange{Symbol: symbol, Change: "changed", Before: b, After: a})
+		}
+	}
+	for symbol, a := range after {
+		if _, ok := before[symbol]; !ok {
+			changes = append(changes, APIChange{Symbol: symbol, Change: "added", After: a})
+		}
+	}
+	slices.SortFunc(changes, func(a, b APIChange) int {
+		return strings.Compare(a.Symbol, b.Symbol)
+	})
+	return changes
+}
+
+// goAPI returns the exported API of every non-internal, non-main package of
+// the Go module in the given directory, keyed by symbol.
+func goAPI(dir string, fset *token.FileSet, std types.Importer) (map[string]string, error) {
+	bts, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	modPath := modulePath(bts)
+	if modPath == "" {
+		return nil, errors.New("go.mod has no module path")
+	}
+
+	imp := &apiImporter{
+		root:    dir,
+		modPath: modPath,
+		fset:    fset,
+		std:     std,
+		pkgs:    map[string]*types.Package{},
+		fakes:   map[*types.Package]bool{},
+	}
+
+	api := map[string]string{}
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if p != dir {
+			if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+				name == "vendor" || name == "testdata" || name == "internal" {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(p, "go.mod")); err == nil {
+				return filepath.SkipDir // nested module
+			}
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		pkg, err := imp.load(path.Join(modPath, rel))
+		if err != nil {
+			return err
+		}
+		if pkg == nil || pkg.Name() == "main" {
+			return nil
+		}
+		for symbol, desc := range packageAPI(pkg, imp.qualifier(pkg)) {
+			if rel != "." {
+				symbol = rel + "." + symbol
+			}
+			api[symbol] = desc
+		}
+		return nil
+	})
+	return api, err
+}
+
+// packageAPI returns the exported symbols of the given package.
+// Exported struct fields and methods are symbols of their own, so adding them
+// is not a change to their type.
+func packageAPI(pkg *types.Package, qualifier types.Qualifier) map[string]string {
+	api := map[string]string{}
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+
+		tn, ok := obj.(*types.TypeName)
+		if !ok || tn.IsAlias() {
+			api[name] = types.ObjectString(obj, qualifier)
+			continue
+		}
+
+		switch u := tn.Type().Underlying().(type) {
+		case *types.Struct:
+			api[name] = "type " + name + " struct"
+			for i := range u.NumFields() {
+				f := u.Field(i)
+				if f.Exported() {
+					api[name+"."+f.Name()] = types.ObjectString(f, qualifier)
+				}
+			}
+		default:
+			api[name] = "type " + name + " " + types.TypeString(u, qualifier)
+		}
+
+		if _, ok := tn.Type().Underlying().(*types.Interface); ok {
+			continue // methods are part of the type
+		}
+		mset := types.NewMethodSet(types.NewPointer(tn.Type()))
+		for i := range mset.Len() {
+			m := mset.At(i).Obj()
+			if m.Exported() {
+				api[name+"."+m.Name()] = types.ObjectString(m, qualifier)
+			}
+		}
+	}
+	return api
+}
+
+// apiImporter type-checks the packages of a module from source, falling back
+// to the standard library, and then to empty packages.
+type apiImporter struct {
+	root    string
+	modPath string
+	fset    *token.FileSet
+	std     types.Importer
+	pkgs    map[string]*types.Package
+	// fakes are the packages that could not be imported, and are replaced by
+	// empty ones.
+	fakes map[*types.Package]bool
+}
+
+// qualifier returns a qualifier that leaves the given package unqualified, and
+// names the other packages of the module by their path relative to it, so the
+// API of the module can be compared across module path changes.
+func (imp *apiImporter) qualifier(current *types.Package) types.Qualifier {
+	return func(pkg *types.Package) string {
+		switch {
+		case pkg == current:
+			return ""
+		case pkg.Path() == imp.modPath:
+			return pkg.Name()
+		}
+		if rel, ok := strings.CutPrefix(pkg.Path(), imp.modPath+"/"); ok {
+			return rel
+		}
+		return pkg.Path()
+	}
+}
+
+// Import implements types.Importer.
+func (imp *apiImporter) Import(importPath string) (*types.Package, error) {
+	pkg, err := imp.load(importPath)
+	if err == nil && pkg == nil {
+		err = fmt.Errorf("could not import %s", importPath)
+	}
+	return pkg, err
+}
+
+// load returns the type-checked package with the given path, or nil if it is
+// a directory of the module without Go files, or part of an import cycle.
+func (imp *apiImporter) load(importPath string) (*types.Package, error) {
+	if pkg, ok := imp.pkgs[importPath]; ok {
+		return pkg, nil
+	}
+
+	rel, inModule := strings.CutPrefix(importPath, imp.modPath)
+	if !inModule || (rel != "" && !strings.HasPrefix(rel, "/")) {
+		var pkg *types.Package
+		err := errors.New("not in the standard library")
+		if first, _, _ := strings.Cut(importPath, "/"); !strings.Contains(first, ".") {
+			pkg, err = imp.std.Import(importPath)
+		}
+		if err != nil {
+			pkg = types.NewPackage(importPath, packageName(importPath))
+			pkg.MarkComplete()
+			imp.fakes[pkg] = true
+		}
+		imp.pkgs[importPath] = pkg
+		return pkg, nil
+	}
+
+	// avoids import cycles from recursing forever.
+	imp.pkgs[importPath] = nil
+
+	dir := filepath.Join(imp.root, filepath.FromSlash(strings.TrimPrefix(rel, "/")))
+	bp, err := build.Default.ImportDir(dir, 0)
+	var noGo *build.NoGoError
+	if errors.As(err, &noGo) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*ast.File
+	for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+		f, err := parser.ParseFile(imp.fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		if err := imp.declareFakes(f); err != nil {
+			return nil, err
+		}
+	}
+
+	conf := types.Config{
+		Importer:    imp,
+		FakeImportC: true,
+		// type errors, e.g. from unresolved imports, should not prevent the
+		// rest of the API from being compared.
+		Error: func(error) {},
+	}
+	pkg, _ := conf.Check(importPath, imp.fset, files, nil)
+	imp.pkgs[importPath] = pkg
+	return pkg, nil
+}
+
+// declareFakes declares the names the given file uses from packages that
+// could not be imported as types, so they are still named in the API instead
+// of being invalid.
+func (imp *apiImporter) declareFakes(f *ast.File) error {
+	names := map[string]*types.Package{}
+	for _, spec := range f.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return err
+		}
+		if importPath == "C" || importPath == imp.modPath || strings.HasPrefix(importPath, imp.modPath+"/") {
+			continue
+		}
+		pkg, err := imp.load(importPath)
+		if err != nil {
+			return err
+		}
+		if !imp.fakes[pkg] {
+			continue
+		}
+		name := pkg.Name()
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		names[name] = pkg
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		x, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		pkg, ok := names[x.Name]
+		if !ok || pkg.Scope().Lookup(sel.Sel.Name) != nil {
+			return true
+		}
+		tn := types.NewTypeName(token.NoPos, pkg, sel.Sel.Name, nil)
+		types.NewNamed(tn, types.NewStruct(nil, nil), nil)
+		pkg.Scope().Insert(tn)
+		return true
+	})
+	return nil
+}
+
+// packageName guesses the name of a package from its import path.
+func packageName(importPath string) string {
+	name := path.Base(importPath)
+	if majorSuffix.MatchString(name) && path.Dir(importPath) != "." {
+		name = path.Base(path.Dir(importPath))
+	}
+	name, _, _ = strings.Cut(name, ".")
+	return strings.NewReplacer("-", "_").Replace(name)
+}
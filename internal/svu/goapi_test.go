@@ -0,0 +1,117 @@
+package svu
+
+import (
+	"go/importer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoAPI(t *testing.T) {
+	module := func(tb testing.TB, files map[string]string) map[string]string {
+		tb.Helper()
+		dir := tb.TempDir()
+		files["go.mod"] = "module example.com/foo\n\ngo 1.24\n"
+		for name, content := range files {
+			p := filepath.Join(dir, filepath.FromSlash(name))
+			require.NoError(tb, os.MkdirAll(filepath.Dir(p), 0o755))
+			require.NoError(tb, os.WriteFile(p, []byte(content), 0o644))
+		}
+		fset := token.NewFileSet()
+		api, err := goAPI(dir, fset, importer.ForCompiler(fset, "source", nil))
+		require.NoError(tb, err)
+		return api
+	}
+
+	before := module(t, map[string]string{
+		"foo.go": `package foo
+
+import (
+	"example.com/foo/bar"
+	"github.com/unknown/dep"
+)
+
+type T struct {
+	A      int
+	hidden string
+	D      dep.Thing
+}
+
+func (T) M(b bar.B) error { return nil }
+
+type I interface{ Do() }
+
+func F() {}
+
+func unexported() {}
+
+const C = 1
+`,
+		"bar/bar.go":           "package bar\n\ntype B struct{}\n",
+		"internal/x/x.go":      "package x\n\nfunc X() {}\n",
+		"cmd/foo/main.go":      "package main\n\nfunc Main() {}\n",
+		"nested/go.mod":        "module example.com/foo/nested\n",
+		"nested/nested.go":     "package nested\n\nfunc N() {}\n",
+		"testdata/testdata.go": "package testdata\n\nfunc T() {}\n",
+	})
+	require.Equal(t, map[string]string{
+		"T":     "type T struct",
+		"T.A":   "field A int",
+		"T.D":   "field D github.com/unknown/dep.Thing",
+		"T.M":   "func (T).M(b bar.B) error",
+		"I":     "type I interface{Do()}",
+		"F":     "func F()",
+		"C":     "const C untyped int",
+		"bar.B": "type B struct",
+	}, before)
+
+	after := module(t, map[string]string{
+		"foo.go": `package foo
+
+import "example.com/foo/bar"
+
+type T struct {
+	A      int
+	hidden int
+	B      bool
+}
+
+func (T) M(b bar.B) error { return nil }
+
+type I interface{ Do(); Undo() }
+
+func F() {}
+
+func G() {}
+
+const C = 2
+`,
+		"bar/bar.go": "package bar\n\ntype B struct{}\n",
+	})
+
+	changes := diffAPI(before, after)
+	require.Equal(t, []APIChange{
+		{Symbol: "G", Change: "added", After: "func G()"},
+		{Symbol: "I", Change: "changed", Before: "type I interface{Do()}", After: "type I interface{Do(); Undo()}"},
+		{Symbol: "T.B", Change: "added", After: "field B bool"},
+		{Symbol: "T.D", Change: "removed", Before: "field D github.com/unknown/dep.Thing"},
+	}, changes)
+
+	t.Run("classification", func(t *testing.T) {
+		c, ok := apiClassification(changes)
+		require.True(t, ok)
+		require.Equal(t, BumpMajor, c.Bump)
+		require.Equal(t, goAPIRule, c.Rule)
+		require.Equal(t, "exported go api changed: 2 added, 1 removed, 1 changed", c.Title)
+
+		c, ok = apiClassification(changes[:1])
+		require.True(t, ok)
+		require.Equal(t, BumpMinor, c.Bump)
+
+		_, ok = apiClassification(nil)
+		require.False(t, ok)
+	})
+}
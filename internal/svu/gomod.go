@@ -0,0 +1,208 @@
+package svu
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// What to do when a major version bump does not match the major version
+// suffix of the Go module path.
+const (
+	GoMajorCheckError = "error"
+	GoMajorCheckWarn  = "warn"
+	GoMajorCheckOff   = "off"
+)
+
+var majorSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// GoModules returns a component for every Go module in the repository, named
+// after its module path, and following Go's tag conventions: the tag prefix
+// is the module directory followed by "/v", and only commits that changed
+// the module directory, excluding nested modules, are taken into account.
+func GoModules(opts Options) ([]Component, error) {
+	root := repository(opts).Root(opts.Ctx)
+	if root == "" {
+		return nil, errors.New("could not find the repository root")
+	}
+
+	modules := map[string]string{}
+	if err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if p != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "go.mod" {
+			return nil
+		}
+		bts, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+		modules[filepath.ToSlash(rel)] = modulePath(bts)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to find go modules: %w", err)
+	}
+	if len(modules) == 0 {
+		return nil, errors.New("no go modules found")
+	}
+
+	dirs := make([]string, 0, len(modules))
+	for dir := range modules {
+		dirs = append(dirs, dir)
+	}
+	slices.Sort(dirs)
+
+	components := make([]Component, 0, len(dirs))
+	for _, dir := range dirs {
+		components = append(components, goModuleComponent(dir, modules[dir], dirs))
+	}
+	return components, nil
+}
+
+func goModuleComponent(dir, modPath string, dirs []string) Component {
+	prefix := dir
+	if base := path.Base(dir); majorSuffix.MatchString(base) && strings.HasSuffix(modPath, "/"+base) {
+		// major version subdirectories are tagged as their parent, e.g.
+		// the module in foo/v2 is tagged foo/v2.x.y.
+		prefix = path.Dir(dir)
+	}
+	if prefix == "." {
+		prefix = "v"
+	} else {
+		prefix += "/v"
+	}
+
+	// only consider tags of the major version of the module path.
+	pattern := prefix + "[01].*"
+	if major := pathMajor(modPath); major > 1 {
+		pattern = prefix + strconv.FormatUint(major, 10) + ".*"
+	}
+
+	pathspecs := []string{":(top)" + dir}
+	if dir == "." {
+		pathspecs = []string{":(top)"}
+	}
+	for _, other := range dirs {
+		if other != dir && (dir == "." || strings.HasPrefix(other, dir+"/")) {
+			pathspecs = append(pathspecs, ":(top,exclude)"+other)
+		}
+	}
+
+	name := modPath
+	if name == "" {
+		name = dir
+	}
+	return Component{
+		Name:   name,
+		Module: dir,
+		Tag:    ComponentTag{Prefix: prefix, Pattern: pattern},
+		Log:    ComponentLog{Directory: pathspecs},
+	}
+}
+
+// checkGoMajor checks that the module path in the go.mod of the GoModule
+// directory has the major version suffix required by the next version, when
+// it has a different major than the current one.
+//
+// Depending on GoMajorCheck, a mismatch is either an error or a warning.
+// Repositories without a go.mod are not checked.
+func checkGoMajor(opts Options, current *semver.Version, next semver.Version) error {
+	if opts.GoMajorCheck == "" || opts.GoMajorCheck == GoMajorCheckOff {
+		return nil
+	}
+	if next.Major() == current.Major() {
+		return nil
+	}
+
+	root := repository(opts).Root(opts.Ctx)
+	if root == "" {
+		return nil
+	}
+	bts, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(opts.GoModule), "go.mod"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	modPath := modulePath(bts)
+	if modPath == "" {
+		return nil
+	}
+
+	expected := expectedModulePath(modPath, next.Major())
+	if expected == modPath {
+		return nil
+	}
+
+	msg := fmt.Sprintf(
+		"version %s%s requires the go module path %q to be %q",
+		opts.Prefix, next.String(), modPath, expected,
+	)
+	if opts.GoMajorCheck == GoMajorCheckWarn {
+		warnf(opts, "%s", msg)
+		return nil
+	}
+	return errors.New(msg + ": update the module path, or set go.major_check to warn or off")
+}
+
+// expectedModulePath returns the given module path with the suffix required
+// by the given major version, e.g. example.com/foo/v3 for example.com/foo/v2
+// and major 3, or example.com/foo for major 1.
+func expectedModulePath(modPath string, major uint64) string {
+	if pathMajor(modPath) > 0 {
+		modPath = path.Dir(modPath)
+	}
+	if major > 1 {
+		modPath += "/v" + strconv.FormatUint(major, 10)
+	}
+	return modPath
+}
+
+// pathMajor returns the major version suffix of the given module path, e.g. 2
+// for example.com/foo/v2, or 0 if it has none.
+func pathMajor(modPath string) uint64 {
+	base := path.Base(modPath)
+	if !majorSuffix.MatchString(base) {
+		return 0
+	}
+	major, _ := strconv.ParseUint(base[1:], 10, 64)
+	return major
+}
+
+// modulePath returns the module path declared in the given go.mod contents.
+func modulePath(mod []byte) string {
+	for line := range strings.SplitSeq(string(mod), "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+		if p, err := strconv.Unquote(fields[1]); err == nil {
+			return p
+		}
+		return fields[1]
+	}
+	return ""
+}
@@ -0,0 +1,162 @@
+package svu
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModulePath(t *testing.T) {
+	for expected, mod := range map[string]string{
+		"example.com/foo":    "module example.com/foo\n\ngo 1.24\n",
+		"example.com/foo/v2": "// a comment\nmodule   example.com/foo/v2 // another one\n",
+		"example.com/quoted": "module \"example.com/quoted\"\n",
+		"":                   "go 1.24\n",
+	} {
+		t.Run(expected, func(t *testing.T) {
+			require.Equal(t, expected, modulePath([]byte(mod)))
+		})
+	}
+}
+
+func TestPathMajor(t *testing.T) {
+	require.Equal(t, uint64(0), pathMajor("example.com/foo"))
+	require.Equal(t, uint64(1), pathMajor("example.com/foo/v1"))
+	require.Equal(t, uint64(2), pathMajor("example.com/foo/v2"))
+	require.Equal(t, uint64(0), pathMajor("example.com/foo/v2x"))
+}
+
+func TestGoModuleComponent(t *testing.T) {
+	dirs := []string{".", "lib", "lib/nested", "tools", "v2"}
+	for _, tt := range []struct {
+		dir      string
+		modPath  string
+		expected Component
+	}{
+		{
+			dir:     ".",
+			modPath: "example.com/foo",
+			expected: Component{
+				Name:   "example.com/foo",
+				Module: ".",
+				Tag:    ComponentTag{Prefix: "v", Pattern: "v[01].*"},
+				Log: ComponentLog{Directory: []string{
+					":(top)",
+					":(top,exclude)lib",
+					":(top,exclude)lib/nested",
+					":(top,exclude)tools",
+					":(top,exclude)v2",
+				}},
+			},
+		},
+		{
+			dir:     "lib",
+			modPath: "example.com/foo/lib",
+			expected: Component{
+				Name:   "example.com/foo/lib",
+				Module: "lib",
+				Tag:    ComponentTag{Prefix: "lib/v", Pattern: "lib/v[01].*"},
+				Log: ComponentLog{Directory: []string{
+					":(top)lib",
+					":(top,exclude)lib/nested",
+				}},
+			},
+		},
+		{
+			dir:     "tools",
+			modPath: "example.com/foo/tools/v3",
+			expected: Component{
+				Name:   "example.com/foo/tools/v3",
+				Module: "tools",
+				Tag:    ComponentTag{Prefix: "tools/v", Pattern: "tools/v3.*"},
+				Log:    ComponentLog{Directory: []string{":(top)tools"}},
+			},
+		},
+		{
+			dir:     "v2",
+			modPath: "example.com/foo/v2",
+			expected: Component{
+				Name:   "example.com/foo/v2",
+				Module: "v2",
+				Tag:    ComponentTag{Prefix: "v", Pattern: "v2.*"},
+				Log:    ComponentLog{Directory: []string{":(top)v2"}},
+			},
+		},
+	} {
+		t.Run(tt.dir, func(t *testing.T) {
+			require.Equal(t, tt.expected, goModuleComponent(tt.dir, tt.modPath, dirs))
+		})
+	}
+}
+
+func TestExpectedModulePath(t *testing.T) {
+	for _, tt := range []struct {
+		modPath  string
+		major    uint64
+		expected string
+	}{
+		{"example.com/foo", 0, "example.com/foo"},
+		{"example.com/foo", 1, "example.com/foo"},
+		{"example.com/foo", 2, "example.com/foo/v2"},
+		{"example.com/foo/v2", 3, "example.com/foo/v3"},
+		{"example.com/foo/v2", 1, "example.com/foo"},
+	} {
+		t.Run(tt.expected, func(t *testing.T) {
+			require.Equal(t, tt.expected, expectedModulePath(tt.modPath, tt.major))
+		})
+	}
+}
+
+func TestCheckGoMajor(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	require.NoError(t, exec.Command("git", "init").Run())
+
+	v1 := semver.MustParse("1.2.3")
+	v2 := semver.MustParse("2.0.0")
+	opts := Options{Ctx: t.Context(), Prefix: "v", GoMajorCheck: GoMajorCheckError}
+
+	t.Run("no go.mod", func(t *testing.T) {
+		require.NoError(t, checkGoMajor(opts, v1, *v2))
+	})
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/foo\n"), 0o644))
+
+	t.Run("same major", func(t *testing.T) {
+		require.NoError(t, checkGoMajor(opts, v1, v1.IncMinor()))
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		err := checkGoMajor(opts, v1, *v2)
+		require.ErrorContains(t, err, `version v2.0.0 requires the go module path "example.com/foo" to be "example.com/foo/v2"`)
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		var stderr bytes.Buffer
+		opts := opts
+		opts.GoMajorCheck = GoMajorCheckWarn
+		opts.Stderr = &stderr
+		require.NoError(t, checkGoMajor(opts, v1, *v2))
+		require.Contains(t, stderr.String(), "warning: version v2.0.0 requires")
+	})
+
+	t.Run("off", func(t *testing.T) {
+		opts := opts
+		opts.GoMajorCheck = GoMajorCheckOff
+		require.NoError(t, checkGoMajor(opts, v1, *v2))
+	})
+
+	t.Run("module dir", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "go.mod"), []byte("module example.com/foo/lib/v2\n"), 0o644))
+		opts := opts
+		opts.GoModule = "lib"
+		require.NoError(t, checkGoMajor(opts, v1, *v2))
+		require.Error(t, checkGoMajor(opts, v2, v2.IncMajor()))
+	})
+}
@@ -0,0 +1,205 @@
+package svu
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+)
+
+const (
+	// HookCommitMsg lints the message of every commit.
+	HookCommitMsg = "commit-msg"
+	// HookPrePush verifies the tags being pushed match the version svu
+	// computes for them.
+	HookPrePush = "pre-push"
+)
+
+// hookMarker identifies the hooks created by svu, so only those are
+// overwritten and uninstalled.
+const hookMarker = "# created by svu, remove it with: svu hooks uninstall"
+
+var hookCommands = map[string]string{
+	HookCommitMsg: `exec svu lint "$1"`,
+	HookPrePush:   `exec svu hooks pre-push "$@"`,
+}
+
+// InstallHooks writes the given hooks in the hooks directory of the
+// repository, and returns their paths.
+// Hooks not created by svu are never overwritten.
+func InstallHooks(opts Options, hooks []string) ([]string, error) {
+	dir, err := repository(opts).HooksDir(opts.Ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the hooks directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, hook := range hooks {
+		command, ok := hookCommands[hook]
+		if !ok {
+			return result, fmt.Errorf("invalid hook: %q: valid options are %q and %q", hook, HookCommitMsg, HookPrePush)
+		}
+		p := filepath.Join(dir, hook)
+		ours, err := isSvuHook(p)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return result, err
+		}
+		if err == nil && !ours {
+			return result, fmt.Errorf("%s already exists, and was not created by svu", p)
+		}
+		content := "#!/bin/sh\n" + hookMarker + "\n" + command + "\n"
+		if err := os.WriteFile(p, []byte(content), 0o755); err != nil {
+			return result, fmt.Errorf("failed to write %s: %w", p, err)
+		}
+		log.Printf("installed %s", p)
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// UninstallHooks removes the hooks created by svu from the hooks directory of
+// the repository, and returns their paths.
+func UninstallHooks(opts Options) ([]string, error) {
+	dir, err := repository(opts).HooksDir(opts.Ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the hooks directory: %w", err)
+	}
+
+	var result []string
+	for _, hook := range []string{HookCommitMsg, HookPrePush} {
+		p := filepath.Join(dir, hook)
+		ours, err := isSvuHook(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+		if !ours {
+			log.Printf("%s was not created by svu, keeping it", p)
+			continue
+		}
+		if err := os.Remove(p); err != nil {
+			return result, err
+		}
+		log.Printf("uninstalled %s", p)
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+func isSvuHook(p string) (bool, error) {
+	bts, err := os.ReadFile(p)
+	if err != nil {
+		return false, err
+	}
+	return strings.Contains(string(bts), hookMarker), nil
+}
+
+// PushedTags returns the tags created by a push, given the lines git writes
+// to the standard input of the pre-push hook.
+func PushedTags(r io.Reader) ([]string, error) {
+	var tags []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 4 {
+			continue
+		}
+		tag, ok := strings.CutPrefix(fields[0], "refs/tags/")
+		if !ok || isZeroSHA(fields[1]) || !isZeroSHA(fields[3]) {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags, s.Err()
+}
+
+func isZeroSHA(sha string) bool {
+	return strings.Trim(sha, "0") == ""
+}
+
+// VerifyTags fails if any of the given tags pointing to HEAD is not the
+// version computed for HEAD without it.
+// Tags that do not match the prefix and pattern, or that do not point to
+// HEAD, are not verified.
+func VerifyTags(opts Options, tags []string) error {
+	opts.Action = Next
+	var errs []error
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, opts.Prefix) {
+			log.Printf("not verifying %s, it does not have the prefix %s", tag, opts.Prefix)
+			continue
+		}
+		if match, _ := git.FindTag([]string{tag}, opts.Pattern); match == "" {
+			log.Printf("not verifying %s, it does not match %s", tag, opts.Pattern)
+			continue
+		}
+
+		reachable, err := repository(opts).TagReachable(opts.Ctx, tag)
+		if err != nil {
+			return fmt.Errorf("failed to check tag %s: %w", tag, err)
+		}
+		count, err := repository(opts).CountCommits(opts.Ctx, tag)
+		if err != nil {
+			return fmt.Errorf("failed to count commits since %s: %w", tag, err)
+		}
+		if !reachable || count > 0 {
+			warnf(opts, "not verifying %s, it does not point to HEAD", tag)
+			continue
+		}
+
+		o := opts
+		o.hiddenTag = tag
+		_, version, err := nextTag(o)
+		if err != nil {
+			return err
+		}
+		if expected := opts.Prefix + version; expected != tag {
+			errs = append(errs, fmt.Errorf("tag %s does not match the next version, %s", tag, expected))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// hiddenTagRepository hides the given tag, so the version it tags can be
+// computed again.
+type hiddenTagRepository struct {
+	git.Repository
+	tag string
+}
+
+// ListTags implements git.Repository.
+func (r hiddenTagRepository) ListTags(ctx context.Context, tagMode, tagSort string) ([]string, error) {
+	tags, err := r.Repository.ListTags(ctx, tagMode, tagSort)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, tag := range tags {
+		if tag != r.tag {
+			result = append(result, tag)
+		}
+	}
+	return result, nil
+}
+
+// DescribeTag implements git.Repository.
+func (r hiddenTagRepository) DescribeTag(ctx context.Context, tagMode, tagSort string, pattern string) (string, error) {
+	tags, err := r.ListTags(ctx, tagMode, tagSort)
+	if err != nil {
+		return "", err
+	}
+	return git.FindTag(tags, pattern)
+}
@@ -0,0 +1,96 @@
+package svu
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+	"github.com/caarlos0/svu/v3/pkg/svu/svutest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooks(t *testing.T) {
+	repo := svutest.New()
+	repo.Dir = t.TempDir()
+	opts := Options{Ctx: t.Context(), Repository: repo}
+	dir := filepath.Join(repo.Dir, ".git", "hooks")
+
+	paths, err := InstallHooks(opts, []string{HookCommitMsg, HookPrePush})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(dir, HookCommitMsg),
+		filepath.Join(dir, HookPrePush),
+	}, paths)
+	bts, err := os.ReadFile(filepath.Join(dir, HookCommitMsg))
+	require.NoError(t, err)
+	require.Equal(t, "#!/bin/sh\n"+hookMarker+"\nexec svu lint \"$1\"\n", string(bts))
+	info, err := os.Stat(filepath.Join(dir, HookPrePush))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	t.Run("reinstall", func(t *testing.T) {
+		_, err := InstallHooks(opts, []string{HookCommitMsg})
+		require.NoError(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := InstallHooks(opts, []string{"pre-commit"})
+		require.EqualError(t, err, `invalid hook: "pre-commit": valid options are "commit-msg" and "pre-push"`)
+	})
+
+	t.Run("foreign", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, HookPrePush), []byte("#!/bin/sh\nexit 0\n"), 0o755))
+		_, err := InstallHooks(opts, []string{HookPrePush})
+		require.ErrorContains(t, err, "pre-push already exists, and was not created by svu")
+
+		paths, err := UninstallHooks(opts)
+		require.NoError(t, err)
+		require.Equal(t, []string{filepath.Join(dir, HookCommitMsg)}, paths)
+		require.NoFileExists(t, filepath.Join(dir, HookCommitMsg))
+		require.FileExists(t, filepath.Join(dir, HookPrePush))
+	})
+}
+
+func TestPushedTags(t *testing.T) {
+	zero := strings.Repeat("0", 40)
+	sha := strings.Repeat("a", 40)
+	tags, err := PushedTags(strings.NewReader(strings.Join([]string{
+		"refs/heads/main " + sha + " refs/heads/main " + zero,
+		"refs/tags/v1.2.0 " + sha + " refs/tags/v1.2.0 " + zero,
+		"refs/tags/v1.1.0 " + sha + " refs/tags/v1.1.0 " + sha,
+		"(delete) " + zero + " refs/tags/v1.0.0 " + sha,
+		"",
+	}, "\n")))
+	require.NoError(t, err)
+	require.Equal(t, []string{"v1.2.0"}, tags)
+}
+
+func TestVerifyTags(t *testing.T) {
+	repo := svutest.New()
+	repo.Commit("chore: init")
+	repo.Tag("v1.0.0")
+	repo.Commit("feat: foo")
+	repo.Tag("v2.0.0")
+	repo.Tag("other")
+	var stderr bytes.Buffer
+	opts := Options{
+		Ctx:        t.Context(),
+		Prefix:     "v",
+		TagMode:    git.TagModeAll,
+		Repository: repo,
+		Stderr:     &stderr,
+	}
+
+	require.EqualError(t, VerifyTags(opts, []string{"v2.0.0"}), "tag v2.0.0 does not match the next version, v1.1.0")
+
+	require.NoError(t, repo.DeleteTag(t.Context(), "v2.0.0"))
+	repo.Tag("v1.1.0")
+	require.NoError(t, VerifyTags(opts, []string{"v1.1.0", "other"}))
+
+	repo.Commit("fix: foo")
+	require.NoError(t, VerifyTags(opts, []string{"v1.1.0"}))
+	require.Equal(t, "warning: not verifying v1.1.0, it does not point to HEAD\n", stderr.String())
+}
@@ -0,0 +1,181 @@
+package svu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/caarlos0/svu/v3/internal/conventional"
+	"github.com/caarlos0/svu/v3/internal/git"
+)
+
+const (
+	// ProblemNoRule is reported for commits that are not conventional commits,
+	// and match no rule either.
+	ProblemNoRule = "no_rule"
+	// ProblemUnknownType is reported for conventional commits whose type is
+	// not allowed.
+	ProblemUnknownType = "unknown_type"
+	// ProblemMalformedScope is reported for conventional commits whose scope
+	// is empty, not closed, or padded with spaces.
+	ProblemMalformedScope = "malformed_scope"
+)
+
+// DefaultLintTypes are the conventional commit types allowed when none are
+// given, in addition to the types of the rules.
+var DefaultLintTypes = []string{
+	"build", "chore", "ci", "docs", "feat", "fix", "perf", "refactor",
+	"revert", "style", "test",
+}
+
+var (
+	// lintSkipped are the titles git generates, which are not linted.
+	lintSkipped = regexp.MustCompile(`^(Merge |Revert "|fixup! |squash! |amend! )`)
+	// scissors is the line below which git ignores the commit message, with
+	// commit --verbose.
+	scissors = "# ------------------------ >8 ------------------------"
+)
+
+// LintResult are the problems found in the linted commits.
+type LintResult struct {
+	// Range is the range of the linted commits, empty when a single message
+	// is linted.
+	Range    string        `json:"range,omitempty"`
+	Commits  int           `json:"commits"`
+	Problems []LintProblem `json:"problems"`
+}
+
+// LintProblem is a problem found in a commit.
+type LintProblem struct {
+	SHA   string `json:"sha,omitempty"`
+	Title string `json:"title"`
+	// Kind is one of ProblemNoRule, ProblemUnknownType or
+	// ProblemMalformedScope.
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// Lint checks the commits since the current tag, the same ones Changelog
+// uses, are conventional commits with an allowed type and a well formed
+// scope, or match one of the rules.
+func Lint(opts Options) (LintResult, error) {
+	tag, err := repository(opts).DescribeTag(opts.Ctx, opts.TagMode, opts.TagSort, opts.Pattern)
+	if err != nil {
+		return LintResult{}, fmt.Errorf("failed to get current tag for repo: %w", err)
+	}
+	commits, err := repository(opts).Changelog(opts.Ctx, tag, opts.Directories)
+	if err != nil {
+		return LintResult{}, fmt.Errorf("failed to get changelog: %w", err)
+	}
+	result, err := lint(opts, commits)
+	result.Range = git.ChangelogRange(tag)
+	return result, err
+}
+
+// LintMessage checks the given commit message, as written by git for the
+// commit-msg hook, the same way Lint checks every commit.
+func LintMessage(opts Options, message string) (LintResult, error) {
+	if before, _, ok := strings.Cut(message, scissors); ok {
+		message = before
+	}
+	var lines []string
+	for line := range strings.SplitSeq(message, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	message = strings.TrimSpace(strings.Join(lines, "\n"))
+	title, body, _ := strings.Cut(message, "\n")
+	return lint(opts, []git.Commit{{
+		Title: strings.TrimSpace(title),
+		Body:  strings.TrimSpace(body),
+	}})
+}
+
+func lint(opts Options, commits []git.Commit) (LintResult, error) {
+	rules, err := getRules(opts)
+	if err != nil {
+		return LintResult{}, fmt.Errorf("invalid rules: %w", err)
+	}
+	types := slices.Clone(opts.LintTypes)
+	if len(types) == 0 {
+		types = slices.Clone(DefaultLintTypes)
+	}
+	for _, r := range rules {
+		for _, t := range r.types {
+			if !containsFold(types, t) {
+				types = append(types, t)
+			}
+		}
+	}
+
+	result := LintResult{
+		Commits:  len(commits),
+		Problems: []LintProblem{},
+	}
+	for _, commit := range commits {
+		kind, message := lintCommit(commit, rules, types)
+		if kind == "" {
+			continue
+		}
+		result.Problems = append(result.Problems, LintProblem{
+			SHA:     commit.SHA,
+			Title:   commit.Title,
+			Kind:    kind,
+			Message: message,
+		})
+	}
+	return result, nil
+}
+
+// lintCommit returns the kind of problem found in the given commit, and a
+// description of it, or empty strings if there is none.
+func lintCommit(commit git.Commit, rules []rule, types []string) (string, string) {
+	if lintSkipped.MatchString(commit.Title) {
+		return "", ""
+	}
+	cc, err := conventional.Parse(commit.Title, commit.Body)
+	if errors.Is(err, conventional.ErrNotConventional) {
+		if _, ok := classify(commit, rules); ok {
+			return "", ""
+		}
+		return ProblemNoRule, `not a conventional commit, e.g. "feat(scope): description", and no rule matches it`
+	}
+	if !containsFold(types, cc.Type) {
+		return ProblemUnknownType, fmt.Sprintf("unknown type %q: allowed types are %s", cc.Type, strings.Join(types, ", "))
+	}
+	var perr *conventional.Error
+	if errors.As(err, &perr) {
+		return ProblemMalformedScope, perr.Reason
+	}
+	return "", ""
+}
+
+// JSON returns the result as JSON.
+func (r LintResult) JSON() (string, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert lint result to json: %w", err)
+	}
+	return string(b), nil
+}
+
+// String returns the problems found, one per commit, or an empty string if
+// there are none.
+func (r LintResult) String() string {
+	if len(r.Problems) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, p := range r.Problems {
+		if p.SHA != "" {
+			sb.WriteString(p.SHA[:min(len(p.SHA), 7)] + " ")
+		}
+		fmt.Fprintf(&sb, "%s\n  %s: %s\n", p.Title, p.Kind, p.Message)
+	}
+	fmt.Fprintf(&sb, "found %d problems in %d commits", len(r.Problems), r.Commits)
+	return sb.String()
+}
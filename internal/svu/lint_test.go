@@ -0,0 +1,108 @@
+package svu
+
+import (
+	"testing"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+	"github.com/caarlos0/svu/v3/pkg/svu/svutest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintCommit(t *testing.T) {
+	types := DefaultLintTypes
+	for title, expected := range map[string]string{
+		"feat: foo":                  "",
+		"feat(cli): foo":             "",
+		"feat(cli)!: foo":            "",
+		"fix!: foo":                  "",
+		"FEAT: foo":                  "",
+		"chore(deps): bump foo":      "",
+		"Merge branch 'foo'":         "",
+		`Revert "feat: foo"`:         "",
+		"fixup! feat: foo":           "",
+		"foo: bar":                   ProblemUnknownType,
+		"notfeat: x":                 ProblemUnknownType,
+		"feat(): foo":                ProblemMalformedScope,
+		"feat( ): foo":               ProblemMalformedScope,
+		"feat( cli): foo":            ProblemMalformedScope,
+		"feat(cli: foo":              ProblemMalformedScope,
+		"feat(a(b)): foo":            ProblemMalformedScope,
+		"update readme":              ProblemNoRule,
+		"docs:foo":                   ProblemNoRule,
+		"docs: ":                     ProblemNoRule,
+		"docs: explain why foo!: ok": "",
+	} {
+		t.Run(title, func(t *testing.T) {
+			kind, message := lintCommit(git.Commit{Title: title}, defaultRules, types)
+			require.Equal(t, expected, kind)
+			if expected != "" {
+				require.NotEmpty(t, message)
+			}
+		})
+	}
+
+	t.Run("rules", func(t *testing.T) {
+		rules, err := compileRules([]Rule{
+			{Types: []string{"security"}, Bump: "patch"},
+			{Title: `^\[major\]`, Bump: "major"},
+		})
+		require.NoError(t, err)
+		kind, _ := lintCommit(git.Commit{Title: "[major] foo"}, rules, types)
+		require.Empty(t, kind)
+		kind, _ = lintCommit(git.Commit{Title: "security: foo"}, rules, append(types, "security"))
+		require.Empty(t, kind)
+	})
+}
+
+func TestLint(t *testing.T) {
+	repo := svutest.New()
+	repo.Commit("chore: init")
+	repo.Tag("v1.0.0")
+	repo.Commit("feat: foo")
+	bad := repo.Commit("wip")
+	repo.Commit("security(): foo")
+	opts := Options{
+		Ctx:        t.Context(),
+		Prefix:     "v",
+		TagMode:    git.TagModeAll,
+		Repository: repo,
+	}
+
+	result, err := Lint(opts)
+	require.NoError(t, err)
+	require.Equal(t, "tags/v1.0.0..HEAD", result.Range)
+	require.Equal(t, 3, result.Commits)
+	require.Len(t, result.Problems, 2)
+	require.Equal(t, ProblemUnknownType, result.Problems[0].Kind)
+	require.Equal(t, LintProblem{
+		SHA:     bad,
+		Title:   "wip",
+		Kind:    ProblemNoRule,
+		Message: `not a conventional commit, e.g. "feat(scope): description", and no rule matches it`,
+	}, result.Problems[1])
+
+	opts.LintTypes = []string{"security", "feat"}
+	result, err = Lint(opts)
+	require.NoError(t, err)
+	require.Len(t, result.Problems, 2)
+	require.Equal(t, ProblemMalformedScope, result.Problems[0].Kind)
+
+	out, err := result.JSON()
+	require.NoError(t, err)
+	require.Contains(t, out, `"kind":"malformed_scope"`)
+	require.Contains(t, result.String(), "found 2 problems in 3 commits")
+}
+
+func TestLintMessage(t *testing.T) {
+	opts := Options{Ctx: t.Context()}
+	result, err := LintMessage(opts, "# a comment\nfeat(cli): foo\n\nsome body\n# Please enter the commit message\n# ------------------------ >8 ------------------------\ndiff --git a/foo b/foo\n")
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Commits)
+	require.Empty(t, result.Problems)
+	require.Empty(t, result.String())
+
+	result, err = LintMessage(opts, "feat(): foo\n")
+	require.NoError(t, err)
+	require.Len(t, result.Problems, 1)
+	require.Equal(t, "feat(): foo\n  malformed_scope: the scope is empty\nfound 1 problems in 1 commits", result.String())
+}
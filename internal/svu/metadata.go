@@ -0,0 +1,69 @@
+package svu
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// metadataData is the data available to the metadata template.
+// Its fields are methods, so git is only run for the ones used.
+type metadataData struct {
+	opts Options
+	tag  string
+}
+
+// SHA returns the SHA of the HEAD commit.
+func (d metadataData) SHA() (string, error) {
+	return repository(d.opts).HeadSHA(d.opts.Ctx)
+}
+
+// ShortSHA returns the first 7 characters of the SHA of the HEAD commit.
+func (d metadataData) ShortSHA() (string, error) {
+	sha, err := d.SHA()
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	return sha, err
+}
+
+// CommitsSinceTag returns the number of commits since the current tag.
+func (d metadataData) CommitsSinceTag() (int, error) {
+	return repository(d.opts).CountCommits(d.opts.Ctx, d.tag)
+}
+
+// CommitTimestamp returns the committer date of the HEAD commit, as a unix
+// timestamp.
+func (d metadataData) CommitTimestamp() (int64, error) {
+	t, err := repository(d.opts).HeadTime(d.opts.Ctx)
+	return t.Unix(), err
+}
+
+// Branch returns the current branch, sanitized the same way as prereleases
+// derived from it, or an empty string if HEAD is detached.
+func (d metadataData) Branch() (string, error) {
+	branch, err := repository(d.opts).CurrentBranch(d.opts.Ctx)
+	return sanitizePreRelease(branch, 0), err
+}
+
+// Dirty returns true if the working tree has uncommitted changes.
+func (d metadataData) Dirty() (bool, error) {
+	return repository(d.opts).IsDirty(d.opts.Ctx)
+}
+
+// renderMetadata returns the metadata, rendering it as a Go template with
+// git information about the given current tag, if it is one.
+func renderMetadata(tag string, opts Options) (string, error) {
+	if !strings.Contains(opts.Metadata, "{{") {
+		return opts.Metadata, nil
+	}
+	t, err := template.New("metadata").Parse(opts.Metadata)
+	if err != nil {
+		return "", fmt.Errorf("invalid metadata template: %w", err)
+	}
+	var sb strings.Builder
+	if err := t.Execute(&sb, metadataData{opts: opts, tag: tag}); err != nil {
+		return "", fmt.Errorf("failed to render metadata: %w", err)
+	}
+	return sb.String(), nil
+}
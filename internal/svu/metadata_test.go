@@ -0,0 +1,63 @@
+package svu
+
+import (
+	"testing"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+	"github.com/caarlos0/svu/v3/pkg/svu/svutest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadata(t *testing.T) {
+	repo := svutest.New()
+	repo.Branch = "feat/login"
+	repo.Commit("chore: init")
+	repo.Tag("v1.2.3")
+	repo.Commit("chore: foo")
+	head := repo.Commit("fix: bar")
+
+	opts := Options{
+		Ctx:        t.Context(),
+		Action:     Next,
+		Prefix:     "v",
+		TagMode:    git.TagModeCurrent,
+		Repository: repo,
+	}
+
+	for metadata, expected := range map[string]string{
+		"":                                      "v1.2.4",
+		"build.1":                               "v1.2.4+build.1",
+		"{{.ShortSHA}}.{{.CommitsSinceTag}}":    "v1.2.4+" + head[:7] + ".2",
+		"{{.SHA}}":                              "v1.2.4+" + head,
+		"{{.CommitTimestamp}}":                  "v1.2.4+180",
+		"{{.Branch}}{{if .Dirty}}.dirty{{end}}": "v1.2.4+feat-login",
+	} {
+		t.Run(metadata, func(t *testing.T) {
+			opts := opts
+			opts.Metadata = metadata
+			v, err := Version(opts)
+			require.NoError(t, err)
+			require.Equal(t, expected, v)
+		})
+	}
+
+	t.Run("dirty", func(t *testing.T) {
+		repo.Dirty = true
+		t.Cleanup(func() { repo.Dirty = false })
+		opts := opts
+		opts.Metadata = "{{.ShortSHA}}{{if .Dirty}}.dirty{{end}}"
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.2.4+"+head[:7]+".dirty", v)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		opts := opts
+		opts.Metadata = "{{.Nope}}"
+		_, err := Version(opts)
+		require.ErrorContains(t, err, "failed to render metadata")
+		opts.Metadata = "{{.SHA"
+		_, err = Version(opts)
+		require.ErrorContains(t, err, "invalid metadata template")
+	})
+}
@@ -0,0 +1,111 @@
+package svu
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/caarlos0/svu/v3/internal/git"
+)
+
+// DefaultPreReleaseTemplate is the prerelease template used when none is
+// given.
+const DefaultPreReleaseTemplate = "{{.Branch}}"
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+// preReleaseData is the data available to the prerelease template.
+type preReleaseData struct {
+	// Branch is the sanitized name of the current branch.
+	Branch string
+}
+
+// branchPreRelease returns the given options with the prerelease derived
+// from the current branch, if PreReleaseFromBranch is set and no prerelease
+// was given. Only the stable tags, and the tags of that prerelease, are
+// considered then.
+func branchPreRelease(opts Options) (Options, error) {
+	if opts.Action != PreRelease || opts.PreRelease != "" || !opts.PreReleaseFromBranch {
+		return opts, nil
+	}
+	branch, err := repository(opts).CurrentBranch(opts.Ctx)
+	if err != nil {
+		return opts, fmt.Errorf("failed to get current branch: %w", err)
+	}
+	if branch == "" {
+		log.Println("HEAD is detached, not deriving the prerelease from the branch")
+		return opts, nil
+	}
+
+	tmpl := opts.PreReleaseTemplate
+	if tmpl == "" {
+		tmpl = DefaultPreReleaseTemplate
+	}
+	t, err := template.New("prerelease").Parse(tmpl)
+	if err != nil {
+		return opts, fmt.Errorf("invalid prerelease template: %w", err)
+	}
+	var sb strings.Builder
+	if err := t.Execute(&sb, preReleaseData{
+		Branch: sanitizePreRelease(branch, 0),
+	}); err != nil {
+		return opts, fmt.Errorf("failed to render prerelease: %w", err)
+	}
+
+	prerelease := sanitizePreRelease(sb.String(), opts.PreReleaseMaxLength)
+	if prerelease == "" {
+		return opts, fmt.Errorf("could not derive a prerelease from branch %q", branch)
+	}
+	log.Printf("using prerelease %s from branch %s", prerelease, branch)
+	opts.PreRelease = prerelease
+	opts.preReleaseSeries = prerelease
+	return opts, nil
+}
+
+// sanitizePreRelease makes the given string a valid prerelease identifier:
+// lowercase, with every run of other characters than letters and digits
+// replaced by a "-", and at most maxLength long, if set.
+// Numeric identifiers can not have leading zeros, so they are removed.
+func sanitizePreRelease(s string, maxLength int) string {
+	s = nonAlphanumeric.ReplaceAllString(strings.ToLower(s), "-")
+	s = strings.Trim(s, "-")
+	if maxLength > 0 && len(s) > maxLength {
+		s = strings.TrimRight(s[:maxLength], "-")
+	}
+	if s != "" && strings.Trim(s, "0123456789") == "" {
+		s = strings.TrimLeft(s, "0")
+		if s == "" {
+			s = "0"
+		}
+	}
+	return s
+}
+
+// seriesRepository only describes stable tags, and the tags of the given
+// prerelease series, so the prereleases of other branches are ignored.
+type seriesRepository struct {
+	git.Repository
+	prefix string
+	series string
+}
+
+// DescribeTag implements git.Repository.
+func (r seriesRepository) DescribeTag(ctx context.Context, tagMode, tagSort string, pattern string) (string, error) {
+	tags, err := r.ListTags(ctx, tagMode, tagSort)
+	if err != nil {
+		return "", err
+	}
+	var result []string
+	for _, tag := range tags {
+		v, err := semver.NewVersion(strings.TrimPrefix(tag, r.prefix))
+		if err == nil && v.Prerelease() != "" && strings.Split(v.Prerelease(), ".")[0] != r.series {
+			continue
+		}
+		result = append(result, tag)
+	}
+	return git.FindTag(result, pattern)
+}
@@ -0,0 +1,104 @@
+package svu
+
+import (
+	"testing"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+	"github.com/caarlos0/svu/v3/pkg/svu/svutest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizePreRelease(t *testing.T) {
+	for _, tt := range []struct {
+		branch    string
+		maxLength int
+		expected  string
+	}{
+		{"feat/login", 0, "feat-login"},
+		{"Feat/Login_Page", 0, "feat-login-page"},
+		{"--weird//branch--", 0, "weird-branch"},
+		{"feat/login-page", 8, "feat-log"},
+		{"feat/login-page", 5, "feat"},
+		{"日本", 0, ""},
+		{"0123", 0, "123"},
+		{"000", 0, "0"},
+		{"0123-fix", 0, "0123-fix"},
+		{"0123-fix", 3, "12"},
+	} {
+		t.Run(tt.branch, func(t *testing.T) {
+			require.Equal(t, tt.expected, sanitizePreRelease(tt.branch, tt.maxLength))
+		})
+	}
+}
+
+func TestBranchPreRelease(t *testing.T) {
+	repo := svutest.New()
+	repo.Branch = "feat/login"
+	repo.Commit("chore: init")
+	repo.Tag("v1.2.0")
+	repo.TagUnmerged("v1.3.0-other.7")
+	repo.Commit("feat: login")
+
+	opts := Options{
+		Ctx:                  t.Context(),
+		Action:               PreRelease,
+		Prefix:               "v",
+		TagMode:              git.TagModeAll,
+		Repository:           repo,
+		PreReleaseFromBranch: true,
+	}
+
+	v, err := Version(opts)
+	require.NoError(t, err)
+	require.Equal(t, "v1.3.0-feat-login.0", v)
+
+	repo.Tag(v)
+	repo.Commit("fix: login")
+	v, err = Version(opts)
+	require.NoError(t, err)
+	require.Equal(t, "v1.3.0-feat-login.1", v)
+
+	t.Run("template", func(t *testing.T) {
+		opts := opts
+		opts.PreReleaseTemplate = "pr.{{.Branch}}"
+		opts.PreReleaseMaxLength = 7
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.3.0-pr-feat.0", v)
+	})
+
+	t.Run("explicit", func(t *testing.T) {
+		opts := opts
+		opts.PreRelease = "feat-login"
+		opts.TagMode = git.TagModeCurrent
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.3.0-feat-login.1", v)
+	})
+
+	t.Run("detached", func(t *testing.T) {
+		repo.Branch = ""
+		t.Cleanup(func() { repo.Branch = "feat/login" })
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.4.0-other.0", v)
+	})
+
+	t.Run("numeric", func(t *testing.T) {
+		repo.Branch = "0123"
+		t.Cleanup(func() { repo.Branch = "feat/login" })
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.3.0-123.0", v)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		opts := opts
+		opts.PreReleaseTemplate = "{{.Nope}}"
+		_, err := Version(opts)
+		require.ErrorContains(t, err, "failed to render prerelease")
+		opts.PreReleaseTemplate = "---"
+		_, err = Version(opts)
+		require.EqualError(t, err, `could not derive a prerelease from branch "feat/login"`)
+	})
+}
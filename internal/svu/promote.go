@@ -0,0 +1,91 @@
+package svu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+const (
+	// VerifyNone promotes the prerelease regardless of the commits since it.
+	VerifyNone = "none"
+	// VerifySameCommit only promotes the prerelease if HEAD is the commit it
+	// tags.
+	VerifySameCommit = "same_commit"
+	// VerifyNoBump only promotes the prerelease if none of the commits since
+	// it would bump the version.
+	VerifyNoBump = "no_bump"
+)
+
+// promote returns the final version of the current prerelease, without its
+// prerelease, and with the metadata set in the options, if any.
+func promote(current *semver.Version, tag string, opts Options) (semver.Version, error) {
+	if opts.PreRelease != "" {
+		return *current, errors.New("a prerelease can not be set when promoting")
+	}
+	if current.Prerelease() == "" {
+		return *current, fmt.Errorf("current version %s is not a prerelease", current)
+	}
+	result, err := current.SetPrerelease("")
+	if err != nil {
+		return result, err
+	}
+	result, err = result.SetMetadata("")
+	if err != nil {
+		return result, err
+	}
+
+	switch opts.PromoteVerify {
+	case "", VerifyNone:
+	case VerifySameCommit:
+		count, err := repository(opts).CountCommits(opts.Ctx, tag)
+		if err != nil {
+			return result, fmt.Errorf("failed to count commits: %w", err)
+		}
+		if count > 0 {
+			return result, fmt.Errorf("HEAD is not the commit tagged %s: promote it from the tagged commit", tag)
+		}
+	case VerifyNoBump:
+		rules, err := getRules(opts)
+		if err != nil {
+			return result, fmt.Errorf("invalid rules: %w", err)
+		}
+		changes, err := repository(opts).Changelog(opts.Ctx, tag, opts.Directories)
+		if err != nil {
+			return result, fmt.Errorf("failed to get changelog: %w", err)
+		}
+		var bumps []string
+		for _, c := range classifyAll(changes, rules) {
+			if c.Bump != BumpNone {
+				bumps = append(bumps, fmt.Sprintf("%s %s (%s)", c.SHA, c.Title, c.Bump))
+			}
+		}
+		if len(bumps) > 0 {
+			return result, fmt.Errorf("found changes since %s that bump the version: %s", tag, strings.Join(bumps, ", "))
+		}
+	default:
+		return result, fmt.Errorf(
+			"invalid promote.verify: %q: valid options are %q, %q and %q",
+			opts.PromoteVerify,
+			VerifyNone,
+			VerifySameCommit,
+			VerifyNoBump,
+		)
+	}
+
+	exists, err := repository(opts).TagExists(opts.Ctx, opts.Prefix+result.String())
+	if err != nil {
+		return result, fmt.Errorf("failed to check if tag exists: %w", err)
+	}
+	if exists {
+		return result, fmt.Errorf("tag %s%s already exists", opts.Prefix, result)
+	}
+
+	metadata, err := renderMetadata(tag, opts)
+	if err != nil {
+		return result, err
+	}
+	return result.SetMetadata(metadata)
+}
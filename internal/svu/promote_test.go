@@ -0,0 +1,111 @@
+package svu
+
+import (
+	"testing"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+	"github.com/caarlos0/svu/v3/pkg/svu/svutest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromote(t *testing.T) {
+	setup := func() *svutest.Repository {
+		repo := svutest.New()
+		repo.Commit("chore: init")
+		repo.Tag("v1.2.0")
+		repo.Commit("feat: foo")
+		repo.Tag("v1.3.0-rc.4+build.1")
+		return repo
+	}
+	opts := Options{
+		Ctx:     t.Context(),
+		Action:  Promote,
+		Prefix:  "v",
+		TagMode: git.TagModeCurrent,
+	}
+
+	for _, verify := range []string{"", VerifyNone, VerifySameCommit, VerifyNoBump} {
+		t.Run("verify "+verify, func(t *testing.T) {
+			opts := opts
+			opts.Repository = setup()
+			opts.PromoteVerify = verify
+			v, err := Version(opts)
+			require.NoError(t, err)
+			require.Equal(t, "v1.3.0", v)
+		})
+	}
+
+	t.Run("same commit", func(t *testing.T) {
+		repo := setup()
+		repo.Commit("docs: foo")
+		opts := opts
+		opts.Repository = repo
+		opts.PromoteVerify = VerifySameCommit
+		_, err := Version(opts)
+		require.ErrorContains(t, err, "HEAD is not the commit tagged v1.3.0-rc.4+build.1")
+
+		opts.PromoteVerify = VerifyNoBump
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.3.0", v)
+	})
+
+	t.Run("no bump", func(t *testing.T) {
+		repo := setup()
+		sha := repo.Commit("fix: foo")
+		opts := opts
+		opts.Repository = repo
+		opts.PromoteVerify = VerifyNoBump
+		_, err := Version(opts)
+		require.ErrorContains(t, err, "found changes since v1.3.0-rc.4+build.1 that bump the version: "+sha+" fix: foo (patch)")
+
+		opts.PromoteVerify = VerifyNone
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.3.0", v)
+	})
+
+	t.Run("not a prerelease", func(t *testing.T) {
+		repo := setup()
+		repo.Tag("v1.3.0")
+		opts := opts
+		opts.Repository = repo
+		_, err := Version(opts)
+		require.ErrorContains(t, err, "current version 1.3.0 is not a prerelease")
+	})
+
+	t.Run("already exists", func(t *testing.T) {
+		repo := setup()
+		repo.Tag("v1.3.0")
+		opts := opts
+		opts.Repository = repo
+		opts.Pattern = "v*-rc*"
+		_, err := Version(opts)
+		require.ErrorContains(t, err, "tag v1.3.0 already exists")
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		opts := opts
+		opts.Repository = setup()
+		opts.Metadata = "build.{{.CommitsSinceTag}}"
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.3.0+build.0", v)
+	})
+
+	t.Run("prerelease", func(t *testing.T) {
+		opts := opts
+		opts.Repository = setup()
+		opts.PreRelease = "beta"
+		_, err := Version(opts)
+		require.ErrorContains(t, err, "a prerelease can not be set when promoting")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		opts := opts
+		opts.Repository = setup()
+		opts.PromoteVerify = "nope"
+		_, err := Version(opts)
+		require.ErrorContains(t, err, `invalid promote.verify: "nope"`)
+	})
+}
@@ -0,0 +1,122 @@
+package svu
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"path/filepath"
+	"slices"
+)
+
+// DefaultReleaseMessage is the template used for the message of release
+// commits when none is given.
+const DefaultReleaseMessage = "chore(release): {{ .Version }}"
+
+// Release writes the next version in the given files, commits them, and tags
+// that commit, returning the tag name.
+//
+// The commit message is rendered from ReleaseMessage, and the tag is created
+// as by Tag, but never pushed.
+// If any step fails, the files, the index and HEAD are restored, and the tag
+// is deleted if it was created.
+// If DryRun is set, nothing is written, committed nor tagged.
+func Release(opts Options, files []File) (string, error) {
+	if len(files) == 0 {
+		return "", errors.New("no files configured")
+	}
+	dirty, err := repository(opts).IsDirty(opts.Ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to check the working tree: %w", err)
+	}
+	if dirty {
+		return "", errors.New("the working tree has uncommitted changes: commit or stash them before releasing")
+	}
+
+	previous, version, err := nextTag(opts)
+	if err != nil {
+		return "", err
+	}
+	name := opts.Prefix + version
+
+	tagMessage, err := prepareTag(name, previous, opts)
+	if err != nil {
+		return "", err
+	}
+	tmpl := opts.ReleaseMessage
+	if tmpl == "" {
+		tmpl = DefaultReleaseMessage
+	}
+	message, err := renderMessage("release message", tmpl, name, previous, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.DryRun {
+		log.Printf("dry-run: would write %s to %d files, commit them with message: %q\n", version, len(files), message)
+		log.Printf("dry-run: would create tag %s with message: %q\n", name, tagMessage)
+		return name, nil
+	}
+
+	root, err := filesRoot(opts)
+	if err != nil {
+		return "", err
+	}
+	head, err := repository(opts).HeadSHA(opts.Ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	if head == "" {
+		return "", errors.New("cannot release without commits")
+	}
+
+	written, err := writeFiles(root, files, opts.Prefix, version)
+	if err == nil && len(written) == 0 {
+		err = fmt.Errorf("files already have version %s", version)
+	}
+	if err == nil {
+		err = release(opts, slices.Sorted(maps.Keys(written)), message, name, tagMessage)
+	}
+	if err != nil {
+		return "", rollback(opts, err, root, head, name, written)
+	}
+	return name, nil
+}
+
+// release commits the given paths, and tags that commit.
+func release(opts Options, paths []string, message, name, tagMessage string) error {
+	if err := repository(opts).Add(opts.Ctx, paths); err != nil {
+		return fmt.Errorf("failed to stage files: %w", err)
+	}
+	if err := repository(opts).CreateCommit(opts.Ctx, message); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	log.Printf("committed %s\n", message)
+	if err := repository(opts).CreateTag(opts.Ctx, name, tagMessage, opts.Sign); err != nil {
+		return fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+	log.Printf("created tag %s\n", name)
+	return nil
+}
+
+// rollback undoes a failed release: it deletes the tag, if it was created,
+// resets HEAD and the index to the given commit, and restores the written
+// files.
+func rollback(opts Options, cause error, root, head, name string, written map[string][]byte) error {
+	errs := []error{cause}
+	if exists, err := repository(opts).TagExists(opts.Ctx, name); err == nil && exists {
+		if err := repository(opts).DeleteTag(opts.Ctx, name); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete tag %s: %w", name, err))
+		}
+	}
+	if err := repository(opts).Reset(opts.Ctx, head); err != nil {
+		errs = append(errs, fmt.Errorf("failed to reset to %s: %w", head, err))
+	}
+	for path, content := range written {
+		if err := writeFile(filepath.Join(root, path), content); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s: %w", path, err))
+		}
+	}
+	log.Printf("rolled back the release of %s\n", name)
+	return errors.Join(errs...)
+}
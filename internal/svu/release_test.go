@@ -0,0 +1,136 @@
+package svu
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+	"github.com/caarlos0/svu/v3/pkg/svu/svutest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelease(t *testing.T) {
+	files := []File{{Path: "VERSION"}}
+	setup := func(t *testing.T) (Options, *svutest.Repository) {
+		t.Helper()
+		repo := svutest.New()
+		repo.Dir = t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "VERSION"), []byte("1.2.0\n"), 0o644))
+		repo.Commit("chore: init")
+		repo.Tag("v1.2.0")
+		repo.Commit("feat: foo")
+		return Options{
+			Ctx:        t.Context(),
+			Action:     Next,
+			Prefix:     "v",
+			TagMode:    git.TagModeCurrent,
+			Repository: repo,
+		}, repo
+	}
+
+	t.Run("release", func(t *testing.T) {
+		opts, repo := setup(t)
+		opts.Annotate = true
+		name, err := Release(opts, files)
+		require.NoError(t, err)
+		require.Equal(t, "v1.3.0", name)
+
+		bts, err := os.ReadFile(filepath.Join(repo.Dir, "VERSION"))
+		require.NoError(t, err)
+		require.Equal(t, "1.3.0\n", string(bts))
+
+		commits, err := repo.Changelog(t.Context(), "v1.2.0", nil)
+		require.NoError(t, err)
+		require.Equal(t, "chore(release): v1.3.0", commits[0].Title)
+		count, err := repo.CountCommits(t.Context(), "v1.3.0")
+		require.NoError(t, err)
+		require.Zero(t, count)
+		message, _ := repo.TagMessage("v1.3.0")
+		require.Equal(t, "v1.3.0\n\n- feat: foo", message)
+	})
+
+	t.Run("message", func(t *testing.T) {
+		opts, repo := setup(t)
+		opts.ReleaseMessage = "release {{.Version}} since {{.Previous}}"
+		_, err := Release(opts, files)
+		require.NoError(t, err)
+		commits, err := repo.Changelog(t.Context(), "v1.2.0", nil)
+		require.NoError(t, err)
+		require.Equal(t, "release v1.3.0 since v1.2.0", commits[0].Title)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		opts, repo := setup(t)
+		opts.DryRun = true
+		name, err := Release(opts, files)
+		require.NoError(t, err)
+		require.Equal(t, "v1.3.0", name)
+		exists, err := repo.TagExists(t.Context(), "v1.3.0")
+		require.NoError(t, err)
+		require.False(t, exists)
+		bts, err := os.ReadFile(filepath.Join(repo.Dir, "VERSION"))
+		require.NoError(t, err)
+		require.Equal(t, "1.2.0\n", string(bts))
+	})
+
+	t.Run("dirty", func(t *testing.T) {
+		opts, repo := setup(t)
+		repo.Dirty = true
+		_, err := Release(opts, files)
+		require.ErrorContains(t, err, "the working tree has uncommitted changes")
+	})
+
+	t.Run("no files", func(t *testing.T) {
+		opts, _ := setup(t)
+		_, err := Release(opts, nil)
+		require.EqualError(t, err, "no files configured")
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		opts, repo := setup(t)
+		require.NoError(t, os.WriteFile(filepath.Join(repo.Dir, "VERSION"), []byte("1.3.0\n"), 0o644))
+		_, err := Release(opts, files)
+		require.EqualError(t, err, "files already have version 1.3.0")
+	})
+}
+
+func TestReleaseRollback(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	gitRun := func(args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	gitRun("init", "--quiet")
+	gitRun("config", "user.name", "svu")
+	gitRun("config", "user.email", "svu@example.com")
+	// make signing the tag fail, after the release commit was created.
+	gitRun("config", "gpg.program", "false")
+	require.NoError(t, os.WriteFile("VERSION", []byte("1.2.0\n"), 0o644))
+	gitRun("add", "VERSION")
+	gitRun("commit", "--quiet", "--message", "chore: init")
+	gitRun("tag", "v1.2.0")
+	gitRun("commit", "--quiet", "--allow-empty", "--message", "feat: foo")
+	head := gitRun("rev-parse", "HEAD")
+
+	_, err := Release(Options{
+		Ctx:     t.Context(),
+		Action:  Next,
+		Prefix:  "v",
+		TagMode: git.TagModeCurrent,
+		Sign:    true,
+	}, []File{{Path: "VERSION"}})
+	require.ErrorContains(t, err, "failed to create tag v1.3.0")
+
+	require.Equal(t, head, gitRun("rev-parse", "HEAD"))
+	require.Empty(t, gitRun("status", "--porcelain"))
+	require.Empty(t, gitRun("tag", "--list", "v1.3.0"))
+	bts, err := os.ReadFile("VERSION")
+	require.NoError(t, err)
+	require.Equal(t, "1.2.0\n", string(bts))
+}
@@ -0,0 +1,203 @@
+package svu
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/caarlos0/svu/v3/internal/conventional"
+	"github.com/caarlos0/svu/v3/internal/git"
+)
+
+// Bump is the version increment a commit asks for.
+type Bump uint
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (b Bump) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *Bump) UnmarshalText(text []byte) error {
+	bump, err := ParseBump(string(text))
+	if err != nil {
+		return err
+	}
+	*b = bump
+	return nil
+}
+
+// ParseBump parses a bump level: major, minor, patch or none.
+func ParseBump(s string) (Bump, error) {
+	switch strings.ToLower(s) {
+	case "major":
+		return BumpMajor, nil
+	case "minor":
+		return BumpMinor, nil
+	case "patch":
+		return BumpPatch, nil
+	case "none":
+		return BumpNone, nil
+	}
+	return BumpNone, fmt.Errorf("invalid bump: %q: valid options are major, minor, patch and none", s)
+}
+
+// Rule classifies commits into a version bump.
+//
+// Every criteria set in the rule must match for the rule to match.
+// Rules are evaluated in order, and the first one that matches a commit
+// decides its bump.
+type Rule struct {
+	// Name identifies the rule in logs.
+	Name string `mapstructure:"name"`
+	// Types matches the conventional commit type, e.g. feat, fix, perf.
+	Types []string `mapstructure:"types"`
+	// Scopes matches the conventional commit scope.
+	Scopes []string `mapstructure:"scopes"`
+	// Breaking matches only breaking changes.
+	Breaking bool `mapstructure:"breaking"`
+	// Title is a regular expression matched against the commit title.
+	Title string `mapstructure:"title"`
+	// Body is a regular expression matched against the commit body.
+	Body string `mapstructure:"body"`
+	// Bump is the bump level: major, minor, patch or none.
+	Bump string `mapstructure:"bump"`
+}
+
+// DefaultRules are the rules used when none are given.
+var DefaultRules = []Rule{
+	{Name: "breaking", Breaking: true, Bump: "major"},
+	{Name: "feature", Types: []string{"feat"}, Bump: "minor"},
+	{Name: "fix", Types: []string{"fix"}, Bump: "patch"},
+}
+
+var defaultRules = mustCompileRules(DefaultRules)
+
+type rule struct {
+	name     string
+	types    []string
+	scopes   []string
+	breaking bool
+	title    *regexp.Regexp
+	body     *regexp.Regexp
+	bump     Bump
+}
+
+// match reports whether the rule matches the given commit, parsed as cc if
+// ok.
+// The types, scopes and breaking criteria never match commits that are not
+// conventional.
+func (r rule) match(commit git.Commit, cc conventional.Commit, ok bool) bool {
+	if (r.breaking || len(r.types) > 0 || len(r.scopes) > 0) && !ok {
+		return false
+	}
+	if r.breaking && !cc.Breaking {
+		return false
+	}
+	if len(r.types) > 0 && !containsFold(r.types, cc.Type) {
+		return false
+	}
+	if len(r.scopes) > 0 && !containsFold(r.scopes, cc.Scope) {
+		return false
+	}
+	if r.title != nil && !r.title.MatchString(commit.Title) {
+		return false
+	}
+	if r.body != nil && !r.body.MatchString(commit.Body) {
+		return false
+	}
+	return true
+}
+
+func containsFold(list []string, s string) bool {
+	return slices.ContainsFunc(list, func(item string) bool {
+		return strings.EqualFold(item, s)
+	})
+}
+
+// getRules returns the compiled rules from the options, or the default rules if
+// none were given.
+func getRules(opts Options) ([]rule, error) {
+	if len(opts.Rules) == 0 {
+		return defaultRules, nil
+	}
+	return compileRules(opts.Rules)
+}
+
+func compileRules(rules []Rule) ([]rule, error) {
+	result := make([]rule, 0, len(rules))
+	for i, r := range rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("rules[%d]", i)
+		}
+		if len(r.Types) == 0 && len(r.Scopes) == 0 && !r.Breaking && r.Title == "" && r.Body == "" {
+			return nil, fmt.Errorf("rule %s: at least one of types, scopes, breaking, title or body must be set", name)
+		}
+		bump, err := ParseBump(r.Bump)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+		cr := rule{
+			name:     name,
+			types:    r.Types,
+			scopes:   r.Scopes,
+			breaking: r.Breaking,
+			bump:     bump,
+		}
+		if r.Title != "" {
+			cr.title, err = regexp.Compile(r.Title)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: invalid title: %w", name, err)
+			}
+		}
+		if r.Body != "" {
+			cr.body, err = regexp.Compile(r.Body)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: invalid body: %w", name, err)
+			}
+		}
+		result = append(result, cr)
+	}
+	return result, nil
+}
+
+func mustCompileRules(rules []Rule) []rule {
+	result, err := compileRules(rules)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// classify returns the first rule that matches the given commit.
+func classify(commit git.Commit, rules []rule) (rule, bool) {
+	cc, err := conventional.Parse(commit.Title, commit.Body)
+	for _, r := range rules {
+		if r.match(commit, cc, err == nil) {
+			return r, true
+		}
+	}
+	return rule{}, false
+}
@@ -0,0 +1,71 @@
+package svu
+
+import (
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/caarlos0/svu/v3/internal/git"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomRules(t *testing.T) {
+	rules, err := compileRules(append([]Rule{
+		{Name: "breaking", Breaking: true, Bump: "major"},
+		{Name: "docs fixes", Types: []string{"fix"}, Scopes: []string{"docs"}, Bump: "none"},
+		{Name: "patches", Types: []string{"perf", "refactor", "deps"}, Bump: "patch"},
+		{Name: "security", Types: []string{"security"}, Bump: "minor"},
+		{Name: "body", Body: "(?m)^Release-As: major$", Bump: "major"},
+	}, DefaultRules[1:]...))
+	require.NoError(t, err)
+
+	version := semver.MustParse("v1.2.3")
+	for expected, commits := range map[string][]git.Commit{
+		"1.2.3": {{Title: "chore: nothing"}, {Title: "fix(docs): typo"}},
+		"1.2.4": {{Title: "perf: faster"}, {Title: "chore: nothing"}},
+		"1.3.0": {{Title: "deps: update"}, {Title: "security(auth): rotate keys"}},
+		"2.0.0": {{Title: "chore: release", Body: "Release-As: major"}},
+	} {
+		t.Run(expected, func(t *testing.T) {
+			require.Equal(t, expected, findNext(t, version, commits, rules, Options{}).String())
+		})
+	}
+
+	t.Run("default rules still apply", func(t *testing.T) {
+		require.Equal(t, "2.0.0", findNext(t, version, []git.Commit{{Title: "deps!: drop go 1.22"}}, rules, Options{}).String())
+		require.Equal(t, "1.3.0", findNext(t, version, []git.Commit{{Title: "feat: foo"}}, rules, Options{}).String())
+	})
+}
+
+func TestCompileRulesErrors(t *testing.T) {
+	for name, rule := range map[string]Rule{
+		"no criteria":   {Bump: "patch"},
+		"invalid bump":  {Types: []string{"feat"}, Bump: "huge"},
+		"no bump":       {Types: []string{"feat"}},
+		"invalid title": {Title: "(", Bump: "patch"},
+		"invalid body":  {Body: "(", Bump: "patch"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := compileRules([]Rule{rule})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseBump(t *testing.T) {
+	for s, expected := range map[string]Bump{
+		"major": BumpMajor,
+		"Minor": BumpMinor,
+		"patch": BumpPatch,
+		"none":  BumpNone,
+	} {
+		t.Run(s, func(t *testing.T) {
+			bump, err := ParseBump(s)
+			require.NoError(t, err)
+			require.Equal(t, expected, bump)
+		})
+	}
+	_, err := ParseBump("foo")
+	require.Error(t, err)
+	_, err = ParseBump("")
+	require.EqualError(t, err, `invalid bump: "": valid options are major, minor, patch and none`)
+}
@@ -0,0 +1,104 @@
+package svu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+)
+
+const (
+	// deepenStep is the number of commits fetched the first time a shallow
+	// clone is deepened. It doubles every time.
+	deepenStep = 50
+	// maxDeepen is the number of times a shallow clone is deepened before
+	// giving up.
+	maxDeepen = 16
+)
+
+// shallowRepository makes sure the current tag is found in shallow clones,
+// where the history, and the tags, may be incomplete.
+//
+// If the tag is not found, the clone is deepened if autoFetch is set.
+// Otherwise, if a baseline version is set, it is used as the current tag, and
+// every commit available is considered to come after it.
+type shallowRepository struct {
+	git.Repository
+	autoFetch bool
+	baseline  string
+}
+
+// DescribeTag implements git.Repository.
+func (r shallowRepository) DescribeTag(ctx context.Context, tagMode, tagSort string, pattern string) (string, error) {
+	for i, depth := 0, deepenStep; ; i, depth = i+1, depth*2 {
+		tag, err := r.Repository.DescribeTag(ctx, tagMode, tagSort, pattern)
+		shallow, serr := r.Repository.IsShallow(ctx)
+		if serr != nil {
+			return "", fmt.Errorf("failed to check if the repository is shallow: %w", serr)
+		}
+		if !shallow {
+			return tag, err
+		}
+		// tags fetched with --tags may point outside the history available,
+		// so even in TagModeAll the tag must be reachable from HEAD.
+		if err == nil && tag != "" {
+			found, err := r.Repository.TagReachable(ctx, tag)
+			if err != nil {
+				return "", fmt.Errorf("failed to check if tag %s is reachable: %w", tag, err)
+			}
+			if found {
+				return tag, nil
+			}
+		}
+
+		if !r.autoFetch || i == maxDeepen {
+			break
+		}
+		log.Printf("shallow clone: tag not found, fetching %d more commits", depth)
+		if err := r.Repository.Deepen(ctx, depth); err != nil {
+			return "", fmt.Errorf("failed to deepen shallow clone: %w", err)
+		}
+	}
+
+	if r.baseline != "" {
+		log.Printf("shallow clone: tag not found, using baseline %s", r.baseline)
+		return r.baseline, nil
+	}
+	return "", errors.New("the repository is a shallow clone, and the current tag was not found in its history: " +
+		"fetch the full history with 'git fetch --unshallow --tags', " +
+		"set git.auto_fetch to fetch it automatically, " +
+		"or set git.baseline to the version to start from")
+}
+
+// Changelog implements git.Repository.
+func (r shallowRepository) Changelog(ctx context.Context, tag string, dirs []string) ([]git.Commit, error) {
+	tag, err := r.since(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+	return r.Repository.Changelog(ctx, tag, dirs)
+}
+
+// CountCommits implements git.Repository.
+func (r shallowRepository) CountCommits(ctx context.Context, tag string) (int, error) {
+	tag, err := r.since(ctx, tag)
+	if err != nil {
+		return 0, err
+	}
+	return r.Repository.CountCommits(ctx, tag)
+}
+
+// since returns the tag to read the history from: the baseline does not
+// exist, so all the history available is read instead.
+func (r shallowRepository) since(ctx context.Context, tag string) (string, error) {
+	if r.baseline == "" || tag != r.baseline {
+		return tag, nil
+	}
+	exists, err := r.Repository.TagExists(ctx, tag)
+	if err != nil || exists {
+		return tag, err
+	}
+	return "", nil
+}
@@ -0,0 +1,106 @@
+package svu
+
+import (
+	"testing"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+	"github.com/caarlos0/svu/v3/pkg/svu/svutest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShallow(t *testing.T) {
+	setup := func() *svutest.Repository {
+		repo := svutest.New()
+		for range 200 {
+			repo.Commit("chore: old")
+		}
+		repo.Tag("v1.2.3")
+		for range 60 {
+			repo.Commit("chore: foo")
+		}
+		repo.Commit("fix: foo")
+		repo.Commit("feat: bar")
+		repo.Shallow(1)
+		return repo
+	}
+	opts := Options{
+		Ctx:     t.Context(),
+		Action:  Next,
+		Prefix:  "v",
+		TagMode: git.TagModeCurrent,
+	}
+
+	t.Run("error", func(t *testing.T) {
+		opts := opts
+		opts.Repository = setup()
+		_, err := Version(opts)
+		require.ErrorContains(t, err, "the repository is a shallow clone")
+		require.ErrorContains(t, err, "git fetch --unshallow --tags")
+	})
+
+	t.Run("auto fetch", func(t *testing.T) {
+		repo := setup()
+		opts := opts
+		opts.Repository = repo
+		opts.AutoFetch = true
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.3.0", v)
+
+		shallow, err := repo.IsShallow(t.Context())
+		require.NoError(t, err)
+		require.True(t, shallow, "should only fetch what is needed")
+	})
+
+	t.Run("baseline", func(t *testing.T) {
+		repo := setup()
+		opts := opts
+		opts.Repository = repo
+		opts.Baseline = "1.4.0"
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.5.0", v)
+
+		opts.Format = "{{.PreviousTag}} {{.CommitsSinceTag}}"
+		v, err = Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.4.0 1", v)
+	})
+
+	t.Run("tag fetched outside the history", func(t *testing.T) {
+		repo := setup()
+		repo.FetchTags()
+		opts := opts
+		opts.Repository = repo
+		opts.TagMode = git.TagModeAll
+		_, err := Version(opts)
+		require.ErrorContains(t, err, "the repository is a shallow clone")
+
+		opts.AutoFetch = true
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.3.0", v)
+	})
+
+	t.Run("tag found", func(t *testing.T) {
+		repo := setup()
+		require.NoError(t, repo.Deepen(t.Context(), 70))
+		opts := opts
+		opts.Repository = repo
+		opts.Baseline = "1.4.0"
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.3.0", v)
+	})
+
+	t.Run("not shallow", func(t *testing.T) {
+		repo := svutest.New()
+		repo.Commit("feat: foo")
+		opts := opts
+		opts.Repository = repo
+		opts.Baseline = "1.4.0"
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v0.1.0", v)
+	})
+}
@@ -4,11 +4,15 @@ package svu
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
-	"regexp"
+	"os"
 	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/caarlos0/svu/v3/internal/git"
@@ -23,13 +27,7 @@ const (
 	Patch
 	Current
 	PreRelease
-)
-
-var (
-	breakingBody = regexp.MustCompile("(?m).*BREAKING[ -]CHANGE:.*")
-	breaking     = regexp.MustCompile(`(?im).*(\w+)(\(.*\))?!:.*`)
-	feature      = regexp.MustCompile(`(?im).*feat(\(.*\))?:.*`)
-	patch        = regexp.MustCompile(`(?im).*fix(\(.*\))?:.*`)
+	Promote
 )
 
 type Options struct {
@@ -40,73 +38,220 @@ type Options struct {
 	PrefixOutput string
 	PreRelease   string
 	Metadata     string
+	Scheme       string
+	CalverFormat string
 	TagMode      string
+	TagSort      string
 	ConfigRoot   string
 	Directories  []string
+	Rules        []Rule
 	Always       bool
 	KeepV0       bool
 	JSON         bool
+	Format       string
+	Explain      bool
+	TagMessage   string
+	Annotate     bool
+	Sign         bool
+	DryRun       bool
+	Push         bool
+	Remote       string
+	VerifyRemote bool
+	DetectGoAPI  bool
+	GoMajorCheck string
+	// GoModule is the directory of the Go module checked by GoMajorCheck,
+	// relative to the repository root.
+	GoModule string
+	// AutoFetch deepens shallow clones until the current tag is found.
+	AutoFetch bool
+	// Baseline is the version used as the current one in shallow clones
+	// where the current tag is not found.
+	Baseline string
+	// PreReleaseFromBranch derives the prerelease of the PreRelease action
+	// from the current branch, when none is given.
+	PreReleaseFromBranch bool
+	// PreReleaseMaxLength is the maximum length of the prerelease derived
+	// from the branch. Zero means no limit.
+	PreReleaseMaxLength int
+	// PreReleaseTemplate is the Go template of the prerelease derived from
+	// the branch. Defaults to DefaultPreReleaseTemplate.
+	PreReleaseTemplate string
+	// PromoteVerify is what the Promote action verifies before promoting a
+	// prerelease: VerifyNone, VerifySameCommit or VerifyNoBump.
+	PromoteVerify string
+	// MaxBump is the biggest bump allowed, usually set by a Branch policy.
+	MaxBump string
+	// OnExceed is what happens when the history asks for a bigger bump than
+	// MaxBump: ExceedError, the default, or ExceedWarn.
+	OnExceed string
+	// ReleaseMessage is the Go template of the message of the commit created
+	// by Release. Defaults to DefaultReleaseMessage.
+	ReleaseMessage string
+	// LintTypes are the conventional commit types allowed by Lint, in
+	// addition to the types of the rules. Defaults to DefaultLintTypes.
+	LintTypes []string
+	// Stderr is where warnings are written to. Defaults to os.Stderr.
+	Stderr io.Writer
+	// Repository is the git repository to use. Defaults to the repository
+	// in the current directory, using the git binary.
+	Repository git.Repository
+
+	// preReleaseSeries is the prerelease derived from the branch, whose tags
+	// are the only prerelease tags considered.
+	preReleaseSeries string
+	// hiddenTag is a tag ignored when looking for the current one.
+	hiddenTag string
 }
 
 type VersionInfo struct {
-	Version    string `json:"version"`
-	Major      uint64 `json:"major"`
-	Minor      uint64 `json:"minor"`
-	Patch      uint64 `json:"patch"`
-	Prefix     string `json:"prefix,omitempty"`
-	Metadata   string `json:"metadata,omitempty"`
-	Prerelease string `json:"prerelease,omitempty"`
-	Build      string `json:"build,omitempty"`
+	Component   string `json:"component,omitempty"`
+	Version     string `json:"version"`
+	Major       uint64 `json:"major"`
+	Minor       uint64 `json:"minor"`
+	Patch       uint64 `json:"patch"`
+	Prefix      string `json:"prefix,omitempty"`
+	Metadata    string `json:"metadata,omitempty"`
+	Prerelease  string `json:"prerelease,omitempty"`
+	Build       string `json:"build,omitempty"`
+	PreviousTag string `json:"previous_tag,omitempty"`
 }
 
 func Version(opts Options) (string, error) {
-	tag, err := git.DescribeTag(opts.Ctx, opts.TagMode, opts.Pattern)
+	opts, err := branchPreRelease(opts)
 	if err != nil {
-		return "", fmt.Errorf("failed to get current tag for repo: %w", err)
+		return "", err
 	}
 
-	current, err := getCurrentVersion(tag, opts.Prefix)
+	if opts.Explain {
+		if opts.Scheme == SchemeCalver {
+			return "", errors.New("explain is not supported with the calver scheme")
+		}
+		ex, err := Explain(opts)
+		if err != nil {
+			return "", err
+		}
+		if opts.JSON {
+			return ex.JSON()
+		}
+		return ex.String(), nil
+	}
+
+	tag, version, err := nextTag(opts)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.JSON || opts.Format != "" {
+		info := versionInfo(version, tag, opts)
+		if opts.Format != "" {
+			return formatOutput(info, opts)
+		}
+		return jsonOutput(info)
+	}
+
+	return opts.Prefix + version, nil
+}
+
+// nextTag returns the current tag and the version computed from it, using the
+// versioning scheme set in the options.
+func nextTag(opts Options) (string, string, error) {
+	tag, version, _, err := nextTagWithLog(opts)
+	return tag, version, err
+}
+
+// nextTagWithLog is nextTag, also returning the commits since the current tag
+// the version was computed from, or nil if they were not read.
+func nextTagWithLog(opts Options) (string, string, []git.Commit, error) {
+	if opts.Scheme == SchemeCalver {
+		return calver(opts, time.Now().UTC())
+	}
+	ex, result, err := compute(opts)
 	if err != nil {
-		return "", fmt.Errorf("could not get current version from tag: '%s': %w", tag, err)
+		return "", "", nil, err
 	}
+	return ex.Tag, result.String(), ex.log, nil
+}
 
-	result, err := nextVersion(current, tag, opts)
+// compute returns the version computed from the current tag, and an
+// explanation of how it was computed.
+func compute(opts Options) (Explanation, semver.Version, error) {
+	tag, err := repository(opts).DescribeTag(opts.Ctx, opts.TagMode, opts.TagSort, opts.Pattern)
 	if err != nil {
-		return "", fmt.Errorf("could not get next tag: '%s': %w", tag, err)
+		return Explanation{}, semver.Version{}, fmt.Errorf("failed to get current tag for repo: %w", err)
 	}
 
-	if opts.JSON {
-		return jsonOutput(result, opts)
+	current, err := getCurrentVersion(tag, opts.Prefix)
+	if err != nil {
+		return Explanation{Tag: tag}, semver.Version{}, fmt.Errorf("could not get current version from tag: '%s': %w", tag, err)
 	}
 
-	return opts.Prefix + result.String(), nil
+	result, ex, err := nextVersion(current, tag, opts)
+	if err != nil {
+		return ex, semver.Version{}, fmt.Errorf("could not get next tag: '%s': %w", tag, err)
+	}
+	if err := checkGoMajor(opts, current, result); err != nil {
+		return ex, semver.Version{}, err
+	}
+	ex.Version = opts.Prefix + result.String()
+	return ex, result, nil
 }
 
+// nextVersion returns the version computed from the current one, and an
+// explanation of how it was computed, which only has the classified commits
+// and the decision for the Next and PreRelease actions.
 func nextVersion(
 	current *semver.Version,
 	tag string,
 	opts Options,
-) (semver.Version, error) {
+) (semver.Version, Explanation, error) {
+	ex := Explanation{
+		Tag:     tag,
+		Current: current.String(),
+		Filters: Filters{
+			TagMode:     opts.TagMode,
+			Pattern:     opts.Pattern,
+			Directories: opts.Directories,
+			KeepV0:      opts.KeepV0,
+			Always:      opts.Always,
+			DetectGoAPI: opts.DetectGoAPI,
+			MaxBump:     opts.MaxBump,
+		},
+	}
 	if opts.Action == Current {
-		return *current, nil
+		return *current, ex, nil
+	}
+	if opts.Action == Promote {
+		result, err := promote(current, tag, opts)
+		return result, ex, err
 	}
 
 	var result semver.Version
 	var err error
 	switch opts.Action {
 	case Next, PreRelease:
-		result, err = findNextWithGitLog(current, tag, opts)
+		result, err = findNextWithGitLog(current, tag, opts, &ex)
 	case Major:
-		result = current.IncMajor()
+		result, err = capVersion(current, BumpMajor, opts)
 	case Minor:
-		result = current.IncMinor()
+		result, err = capVersion(current, BumpMinor, opts)
 	case Patch:
-		result = current.IncPatch()
+		result, err = capVersion(current, BumpPatch, opts)
 	}
 	if err != nil {
-		return result, err
+		return result, ex, err
 	}
 
+	result, err = setPreReleaseAndMetadata(current, result, tag, opts)
+	return result, ex, err
+}
+
+func setPreReleaseAndMetadata(
+	current *semver.Version,
+	result semver.Version,
+	tag string,
+	opts Options,
+) (semver.Version, error) {
+	var err error
 	if opts.Always {
 		c, _ := current.SetMetadata("")
 		c, _ = c.SetPrerelease("")
@@ -125,7 +270,11 @@ func nextVersion(
 		}
 	}
 
-	result, err = result.SetMetadata(opts.Metadata)
+	metadata, err := renderMetadata(tag, opts)
+	if err != nil {
+		return result, err
+	}
+	result, err = result.SetMetadata(metadata)
 	if err != nil {
 		return result, err
 	}
@@ -199,91 +348,195 @@ func getCurrentVersion(tag, prefix string) (*semver.Version, error) {
 	return current, err
 }
 
+// findNextWithGitLog returns the next version based on the commits since the
+// given tag, recording the classified commits and the decision in ex.
 func findNextWithGitLog(
 	current *semver.Version,
 	tag string,
 	opts Options,
+	ex *Explanation,
 ) (semver.Version, error) {
-	log, err := git.Changelog(opts.Ctx, tag, opts.Directories)
+	rules, err := getRules(opts)
 	if err != nil {
-		return semver.Version{}, fmt.Errorf("failed to get changelog: %w", err)
+		return semver.Version{}, fmt.Errorf("invalid rules: %w", err)
 	}
 
-	return findNext(current, log, opts), nil
-}
-
-func isBreaking(commit git.Commit) bool {
-	return breakingBody.MatchString(commit.Body) || breaking.MatchString(commit.Title)
-}
+	ex.Range = git.ChangelogRange(tag)
+	log, err := repository(opts).Changelog(opts.Ctx, tag, opts.Directories)
+	if err != nil {
+		return semver.Version{}, fmt.Errorf("failed to get changelog: %w", err)
+	}
+	ex.log = log
+	ex.Commits = classifyAll(log, rules)
 
-func isFeature(commit git.Commit) bool {
-	return feature.MatchString(commit.Title)
-}
+	commits, api, err := detectGoAPI(opts, tag, ex.Commits)
+	if err != nil {
+		return semver.Version{}, err
+	}
+	ex.API = api
 
-func isPatch(commit git.Commit) bool {
-	return patch.MatchString(commit.Title)
+	next, bump, decision, err := decide(current, commits, opts)
+	ex.Bump = bump
+	ex.Decision = decision
+	return next, err
 }
 
-func findNext(current *semver.Version, changes []git.Commit, opts Options) semver.Version {
-	var major, minor, patch *git.Commit
+func classifyAll(changes []git.Commit, rules []rule) []Classification {
+	result := make([]Classification, 0, len(changes))
 	for _, commit := range changes {
-		if isBreaking(commit) {
-			major = &commit
-			break // no bigger change allowed, so we're done
+		c := Classification{
+			SHA:   commit.SHA,
+			Title: commit.Title,
 		}
-
-		if minor == nil && isFeature(commit) {
-			minor = &commit
-		}
-
-		if patch == nil && isPatch(commit) {
-			patch = &commit
+		if r, ok := classify(commit, rules); ok {
+			c.Bump = r.bump
+			c.Rule = r.name
 		}
+		result = append(result, c)
 	}
+	return result
+}
 
-	if major != nil {
-		if current.Major() == 0 && opts.KeepV0 {
-			log.Printf("found major change, but 'keep v0' is set: %s %s\n", major.SHA, major.Title)
-			return current.IncMinor()
+// decide returns the next version based on the biggest bump in the given
+// commits, the bump it actually applied, and a description of why.
+// It fails if the bump exceeds the branch max_bump.
+func decide(current *semver.Version, commits []Classification, opts Options) (semver.Version, Bump, string, error) {
+	var found *Classification
+	for _, commit := range commits {
+		if found != nil && commit.Bump <= found.Bump {
+			continue
+		}
+		if commit.Bump == BumpNone {
+			continue
+		}
+		found = &commit
+		if found.Bump == BumpMajor {
+			break // no bigger change allowed, so we're done
 		}
-		log.Printf("found major change: %s %s\n", major.SHA, major.Title)
-		return current.IncMajor()
 	}
 
-	if minor != nil {
-		log.Printf("found minor change: %s %s\n", minor.SHA, minor.Title)
-		return current.IncMinor()
+	var next semver.Version
+	var bump Bump
+	var reason string
+	var change string
+	if found != nil {
+		// changes not coming from a commit, e.g. go api changes, have no SHA.
+		change = strings.TrimSpace(found.SHA + " " + found.Title)
+	}
+	switch {
+	case found != nil && found.Bump == BumpMajor && current.Major() == 0 && opts.KeepV0:
+		next, bump = current.IncMinor(), BumpMinor
+		reason = fmt.Sprintf("found major change, but 'keep v0' is set: %s (rule %s)", change, found.Rule)
+	case found != nil:
+		next, bump = incVersion(current, found.Bump), found.Bump
+		reason = fmt.Sprintf("found %s change: %s (rule %s)", found.Bump, change, found.Rule)
+	case opts.Always:
+		next, bump = current.IncPatch(), BumpPatch
+		reason = "found no changes, but 'always' is set"
+	default:
+		next, bump = *current, BumpNone
+		reason = "found no changes"
 	}
+	capped, err := capBump(bump, opts)
+	if err != nil {
+		return next, bump, reason, err
+	}
+	if capped != bump {
+		next, bump = incVersion(current, capped), capped
+		reason += fmt.Sprintf(", capped to %s by the branch max_bump", capped)
+	}
+	log.Println(reason)
+	return next, bump, reason, nil
+}
 
-	if patch != nil {
-		log.Printf("found patch change: %s %s\n", patch.SHA, patch.Title)
-		return current.IncPatch()
+// capVersion increments the given version by the given bump, capped to the
+// branch max_bump.
+func capVersion(current *semver.Version, bump Bump, opts Options) (semver.Version, error) {
+	bump, err := capBump(bump, opts)
+	if err != nil {
+		return *current, err
 	}
+	return incVersion(current, bump), nil
+}
 
-	if opts.Always {
-		log.Printf("found no changes, but 'always' is set")
+// incVersion increments the given version by the given bump.
+func incVersion(current *semver.Version, bump Bump) semver.Version {
+	switch bump {
+	case BumpMajor:
+		return current.IncMajor()
+	case BumpMinor:
+		return current.IncMinor()
+	case BumpPatch:
 		return current.IncPatch()
+	default:
+		return *current
 	}
-	return *current
 }
 
-func jsonOutput(v semver.Version, opts Options) (string, error) {
+func versionInfo(version, tag string, opts Options) VersionInfo {
 	info := VersionInfo{
-		Prefix:     opts.Prefix,
-		Version:    opts.Prefix + v.String(),
-		Major:      v.Major(),
-		Minor:      v.Minor(),
-		Patch:      v.Patch(),
-		Metadata:   v.Metadata(),
-		Prerelease: v.Prerelease(),
+		Prefix:      opts.Prefix,
+		Version:     opts.Prefix + version,
+		PreviousTag: tag,
+	}
+
+	// calendar versions may not be valid semantic versions, in which case
+	// only the full version is set.
+	if v, err := semver.NewVersion(version); err == nil {
+		info.Major = v.Major()
+		info.Minor = v.Minor()
+		info.Patch = v.Patch()
+		info.Metadata = v.Metadata()
+		info.Prerelease = v.Prerelease()
+
+		// Split prerelease into prerelease + build if it has a dot
+		if release := strings.SplitN(v.Prerelease(), ".", 2); len(release) == 2 {
+			info.Prerelease = release[0]
+			info.Build = release[1]
+		}
 	}
 
-	// Split prerelease into prerelease + build if it has a dot
-	if release := strings.SplitN(v.Prerelease(), ".", 2); len(release) == 2 {
-		info.Prerelease = release[0]
-		info.Build = release[1]
+	return info
+}
+
+func repository(opts Options) git.Repository {
+	repo := opts.Repository
+	if repo == nil {
+		repo = git.Exec{}
+	}
+	if opts.hiddenTag != "" {
+		repo = hiddenTagRepository{
+			Repository: repo,
+			tag:        opts.hiddenTag,
+		}
+	}
+	if opts.preReleaseSeries != "" {
+		repo = seriesRepository{
+			Repository: repo,
+			prefix:     opts.Prefix,
+			series:     opts.preReleaseSeries,
+		}
 	}
+	baseline := ""
+	if opts.Baseline != "" {
+		baseline = opts.Prefix + opts.Baseline
+	}
+	return shallowRepository{
+		Repository: repo,
+		autoFetch:  opts.AutoFetch,
+		baseline:   baseline,
+	}
+}
+
+func warnf(opts Options, format string, args ...any) {
+	w := opts.Stderr
+	if w == nil {
+		w = os.Stderr
+	}
+	_, _ = fmt.Fprintf(w, "warning: "+format+"\n", args...)
+}
 
+func jsonOutput(info VersionInfo) (string, error) {
 	b, err := json.Marshal(info)
 	if err != nil {
 		return "", fmt.Errorf("failed to convert version to json: %w", err)
@@ -291,3 +544,38 @@ func jsonOutput(v semver.Version, opts Options) (string, error) {
 
 	return string(b), nil
 }
+
+// formatData is the data available to the format template: the version info,
+// and git information about HEAD.
+// The git information are methods, so git is only run for the ones used.
+type formatData struct {
+	VersionInfo
+	opts Options
+}
+
+// Commit returns the SHA of the HEAD commit, or an empty string if there are
+// no commits yet.
+func (d formatData) Commit() (string, error) {
+	return repository(d.opts).HeadSHA(d.opts.Ctx)
+}
+
+// CommitsSinceTag returns the number of commits since the previous tag.
+func (d formatData) CommitsSinceTag() (int, error) {
+	sha, err := d.Commit()
+	if err != nil || sha == "" {
+		return 0, err
+	}
+	return repository(d.opts).CountCommits(d.opts.Ctx, d.PreviousTag)
+}
+
+func formatOutput(info VersionInfo, opts Options) (string, error) {
+	t, err := template.New("format").Parse(opts.Format)
+	if err != nil {
+		return "", fmt.Errorf("invalid format template: %w", err)
+	}
+	var sb strings.Builder
+	if err := t.Execute(&sb, formatData{VersionInfo: info, opts: opts}); err != nil {
+		return "", fmt.Errorf("failed to format version: %w", err)
+	}
+	return sb.String(), nil
+}
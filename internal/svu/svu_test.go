@@ -6,10 +6,26 @@ import (
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/caarlos0/svu/v3/internal/git"
+	"github.com/caarlos0/svu/v3/pkg/svu/svutest"
 	"github.com/stretchr/testify/require"
 )
 
-func TestIsBreaking(t *testing.T) {
+// bumpOf returns the bump of the given commit with the default rules.
+func bumpOf(commit git.Commit) Bump {
+	r, _ := classify(commit, defaultRules)
+	return r.bump
+}
+
+// findNext returns the next version from the given commits, classified with
+// the given rules.
+func findNext(t *testing.T, current *semver.Version, changes []git.Commit, rules []rule, opts Options) semver.Version {
+	t.Helper()
+	next, _, _, err := decide(current, classifyAll(changes, rules), opts)
+	require.NoError(t, err)
+	return next
+}
+
+func TestClassifyBreaking(t *testing.T) {
 	for _, commit := range []git.Commit{
 		{Title: "feat!: foo"},
 		{Title: "chore(lala)!: foo"},
@@ -17,7 +33,7 @@ func TestIsBreaking(t *testing.T) {
 		{Title: "docs: lalala", Body: "BREAKING-CHANGE: lalal"},
 	} {
 		t.Run(commit.String(), func(t *testing.T) {
-			require.True(t, isBreaking(commit)) // should be a major change
+			require.Equal(t, BumpMajor, bumpOf(commit)) // should be a major change
 		})
 	}
 
@@ -28,20 +44,23 @@ func TestIsBreaking(t *testing.T) {
 		{Title: "docs: BREAKING change: lalal"},
 		{Title: "docs: breaking-change: aehijhk"},
 		{Title: "docs: BREAKING_CHANGE: foo"},
+		{Title: "docs: explain why foo!: bar happens"},
+		{Title: "update readme!: foo bar"},
+		{Title: "docs: lalala", Body: "BREAKING CHANGE: not a footer\n\nthe body goes on"},
 	} {
 		t.Run(commit.String(), func(t *testing.T) {
-			require.False(t, isBreaking(commit)) // should NOT be a major change
+			require.NotEqual(t, BumpMajor, bumpOf(commit)) // should NOT be a major change
 		})
 	}
 }
 
-func TestIsFeature(t *testing.T) {
+func TestClassifyFeature(t *testing.T) {
 	for _, commit := range []git.Commit{
 		{Title: "feat: foo"},
 		{Title: "feat(lalal): foobar"},
 	} {
 		t.Run(commit.String(), func(t *testing.T) {
-			require.True(t, isFeature(commit)) // should be a minor change
+			require.Equal(t, BumpMinor, bumpOf(commit)) // should be a minor change
 		})
 	}
 
@@ -53,20 +72,22 @@ func TestIsFeature(t *testing.T) {
 		{Title: "test: foo"},
 		{Title: "Merge remote-tracking branch 'origin/main'"},
 		{Title: "refactor: foo bar"},
+		{Title: "notfeat: x"},
+		{Title: "docs: add feat: to the readme"},
 	} {
 		t.Run(commit.String(), func(t *testing.T) {
-			require.False(t, isFeature(commit)) // should NOT be a minor change
+			require.NotEqual(t, BumpMinor, bumpOf(commit)) // should NOT be a minor change
 		})
 	}
 }
 
-func TestIsPatch(t *testing.T) {
+func TestClassifyPatch(t *testing.T) {
 	for _, commit := range []git.Commit{
 		{Title: "fix: foo"},
 		{Title: "fix(lalal): lalala"},
 	} {
 		t.Run(commit.String(), func(t *testing.T) {
-			require.True(t, isPatch(commit)) // should be a patch change
+			require.Equal(t, BumpPatch, bumpOf(commit)) // should be a patch change
 		})
 	}
 
@@ -74,9 +95,11 @@ func TestIsPatch(t *testing.T) {
 		{Title: "chore: foobar"},
 		{Title: "docs: something"},
 		{Title: "invalid commit"},
+		{Title: "prefix: foo"},
+		{Title: "chore: fix: foo"},
 	} {
 		t.Run(commit.String(), func(t *testing.T) {
-			require.False(t, isPatch(commit)) // should NOT be a patch change
+			require.NotEqual(t, BumpPatch, bumpOf(commit)) // should NOT be a patch change
 		})
 	}
 }
@@ -88,17 +111,17 @@ func TestFindNext(t *testing.T) {
 	version2 := semver.MustParse("v2.4.12")
 	version3 := semver.MustParse("v3.4.5-beta34+ads")
 	for expected, next := range map[string]semver.Version{
-		"0.4.5": findNext(version0a, []git.Commit{{Title: "chore: should do nothing"}}, Options{Ctx: t.Context()}),
-		"0.4.6": findNext(version0a, []git.Commit{{Title: "fix: inc patch"}}, Options{Ctx: t.Context()}),
-		"0.5.0": findNext(version0a, []git.Commit{{Title: "feat: inc minor"}}, Options{Ctx: t.Context()}),
-		"1.0.0": findNext(version0b, []git.Commit{{Title: "feat!: inc minor"}}, Options{Ctx: t.Context()}),
-		"0.6.0": findNext(version0b, []git.Commit{{Title: "feat!: inc minor"}}, Options{Ctx: t.Context(), KeepV0: true}),
-		"1.2.3": findNext(version1, []git.Commit{{Title: "chore: should do nothing"}}, Options{Ctx: t.Context()}),
-		"1.2.4": findNext(version1, []git.Commit{{Title: "chore: always"}}, Options{Ctx: t.Context(), Always: true}),
-		"1.3.0": findNext(version1, []git.Commit{{Title: "feat: inc major"}}, Options{Ctx: t.Context()}),
-		"2.0.0": findNext(version1, []git.Commit{{Title: "chore!: hashbang incs major"}}, Options{Ctx: t.Context()}),
-		"3.0.0": findNext(version2, []git.Commit{{Title: "feat: something", Body: "BREAKING CHANGE: increases major"}}, Options{Ctx: t.Context()}),
-		"3.5.0": findNext(version3, []git.Commit{{Title: "feat: inc major"}}, Options{Ctx: t.Context()}),
+		"0.4.5": findNext(t, version0a, []git.Commit{{Title: "chore: should do nothing"}}, defaultRules, Options{Ctx: t.Context()}),
+		"0.4.6": findNext(t, version0a, []git.Commit{{Title: "fix: inc patch"}}, defaultRules, Options{Ctx: t.Context()}),
+		"0.5.0": findNext(t, version0a, []git.Commit{{Title: "feat: inc minor"}}, defaultRules, Options{Ctx: t.Context()}),
+		"1.0.0": findNext(t, version0b, []git.Commit{{Title: "feat!: inc minor"}}, defaultRules, Options{Ctx: t.Context()}),
+		"0.6.0": findNext(t, version0b, []git.Commit{{Title: "feat!: inc minor"}}, defaultRules, Options{Ctx: t.Context(), KeepV0: true}),
+		"1.2.3": findNext(t, version1, []git.Commit{{Title: "chore: should do nothing"}}, defaultRules, Options{Ctx: t.Context()}),
+		"1.2.4": findNext(t, version1, []git.Commit{{Title: "chore: always"}}, defaultRules, Options{Ctx: t.Context(), Always: true}),
+		"1.3.0": findNext(t, version1, []git.Commit{{Title: "feat: inc major"}}, defaultRules, Options{Ctx: t.Context()}),
+		"2.0.0": findNext(t, version1, []git.Commit{{Title: "chore!: hashbang incs major"}}, defaultRules, Options{Ctx: t.Context()}),
+		"3.0.0": findNext(t, version2, []git.Commit{{Title: "feat: something", Body: "BREAKING CHANGE: increases major"}}, defaultRules, Options{Ctx: t.Context()}),
+		"3.5.0": findNext(t, version3, []git.Commit{{Title: "feat: inc major"}}, defaultRules, Options{Ctx: t.Context()}),
 	} {
 		t.Run(expected, func(t *testing.T) {
 			require.Equal(t, expected, next.String())
@@ -110,7 +133,7 @@ func TestCmd(t *testing.T) {
 	ver := func() *semver.Version { return semver.MustParse("1.2.3-pre+123") }
 	t.Run("current", func(t *testing.T) {
 		t.Run("version has meta", func(t *testing.T) {
-			v, err := nextVersion(ver(), "v1.2.3", Options{
+			v, _, err := nextVersion(ver(), "v1.2.3", Options{
 				Ctx:    t.Context(),
 				Action: Current,
 			})
@@ -118,7 +141,7 @@ func TestCmd(t *testing.T) {
 			require.Equal(t, "1.2.3-pre+123", v.String())
 		})
 		t.Run("version is clean", func(t *testing.T) {
-			v, err := nextVersion(semver.MustParse("v1.2.3"), "v1.2.3", Options{
+			v, _, err := nextVersion(semver.MustParse("v1.2.3"), "v1.2.3", Options{
 				Ctx:    t.Context(),
 				Action: Current,
 			})
@@ -129,7 +152,7 @@ func TestCmd(t *testing.T) {
 
 	t.Run("minor", func(t *testing.T) {
 		t.Run("clean", func(t *testing.T) {
-			v, err := nextVersion(ver(), "v1.2.3", Options{
+			v, _, err := nextVersion(ver(), "v1.2.3", Options{
 				Ctx:    t.Context(),
 				Action: Minor,
 			})
@@ -137,7 +160,7 @@ func TestCmd(t *testing.T) {
 			require.Equal(t, "1.3.0", v.String())
 		})
 		t.Run("metadata", func(t *testing.T) {
-			v, err := nextVersion(ver(), "v1.2.3", Options{
+			v, _, err := nextVersion(ver(), "v1.2.3", Options{
 				Ctx:      t.Context(),
 				Action:   Minor,
 				Metadata: "124",
@@ -146,7 +169,7 @@ func TestCmd(t *testing.T) {
 			require.Equal(t, "1.3.0+124", v.String())
 		})
 		t.Run("prerelease", func(t *testing.T) {
-			v, err := nextVersion(ver(), "v1.2.3", Options{
+			v, _, err := nextVersion(ver(), "v1.2.3", Options{
 				Ctx:        t.Context(),
 				Action:     Minor,
 				PreRelease: "alpha.1",
@@ -155,7 +178,7 @@ func TestCmd(t *testing.T) {
 			require.Equal(t, "1.3.0-alpha.1", v.String())
 		})
 		t.Run("all", func(t *testing.T) {
-			v, err := nextVersion(ver(), "v1.2.3", Options{
+			v, _, err := nextVersion(ver(), "v1.2.3", Options{
 				Ctx:        t.Context(),
 				Action:     Minor,
 				PreRelease: "alpha.2",
@@ -168,7 +191,7 @@ func TestCmd(t *testing.T) {
 
 	t.Run("patch", func(t *testing.T) {
 		t.Run("clean", func(t *testing.T) {
-			v, err := nextVersion(semver.MustParse("1.2.3"), "v1.2.3", Options{
+			v, _, err := nextVersion(semver.MustParse("1.2.3"), "v1.2.3", Options{
 				Ctx:    t.Context(),
 				Action: Patch,
 			})
@@ -176,7 +199,7 @@ func TestCmd(t *testing.T) {
 			require.Equal(t, "1.2.4", v.String())
 		})
 		t.Run("previous had meta", func(t *testing.T) {
-			v, err := nextVersion(semver.MustParse("1.2.3-alpha.1+1"), "v1.2.3", Options{
+			v, _, err := nextVersion(semver.MustParse("1.2.3-alpha.1+1"), "v1.2.3", Options{
 				Ctx:    t.Context(),
 				Action: Patch,
 			})
@@ -184,7 +207,7 @@ func TestCmd(t *testing.T) {
 			require.Equal(t, "1.2.3", v.String())
 		})
 		t.Run("previous had meta + always", func(t *testing.T) {
-			v, err := nextVersion(semver.MustParse("1.2.3-alpha.1+1"), "v1.2.3", Options{
+			v, _, err := nextVersion(semver.MustParse("1.2.3-alpha.1+1"), "v1.2.3", Options{
 				Ctx:    t.Context(),
 				Action: Patch,
 				Always: true,
@@ -193,7 +216,7 @@ func TestCmd(t *testing.T) {
 			require.Equal(t, "1.2.3", v.String())
 		})
 		t.Run("previous had meta + always, add meta", func(t *testing.T) {
-			v, err := nextVersion(semver.MustParse("1.2.3-alpha.1+1"), "v1.2.3-alpha.1+1", Options{
+			v, _, err := nextVersion(semver.MustParse("1.2.3-alpha.1+1"), "v1.2.3-alpha.1+1", Options{
 				Ctx:        t.Context(),
 				Action:     Patch,
 				Always:     true,
@@ -204,7 +227,7 @@ func TestCmd(t *testing.T) {
 			require.Equal(t, "1.2.3-alpha.2+10", v.String())
 		})
 		t.Run("previous had meta, change it", func(t *testing.T) {
-			v, err := nextVersion(semver.MustParse("1.2.3-alpha.1+1"), "v1.2.3-alpha.1+1", Options{
+			v, _, err := nextVersion(semver.MustParse("1.2.3-alpha.1+1"), "v1.2.3-alpha.1+1", Options{
 				Ctx:        t.Context(),
 				Action:     Patch,
 				PreRelease: "alpha.2",
@@ -214,7 +237,7 @@ func TestCmd(t *testing.T) {
 			require.Equal(t, "1.2.3-alpha.2+10", v.String())
 		})
 		t.Run("metadata", func(t *testing.T) {
-			v, err := nextVersion(semver.MustParse("1.2.3"), "v1.2.3", Options{
+			v, _, err := nextVersion(semver.MustParse("1.2.3"), "v1.2.3", Options{
 				Ctx:      t.Context(),
 				Action:   Patch,
 				Metadata: "124",
@@ -223,7 +246,7 @@ func TestCmd(t *testing.T) {
 			require.Equal(t, "1.2.4+124", v.String())
 		})
 		t.Run("prerelease", func(t *testing.T) {
-			v, err := nextVersion(semver.MustParse("1.2.3"), "v1.2.3", Options{
+			v, _, err := nextVersion(semver.MustParse("1.2.3"), "v1.2.3", Options{
 				Ctx:        t.Context(),
 				Action:     Patch,
 				PreRelease: "alpha.1",
@@ -232,7 +255,7 @@ func TestCmd(t *testing.T) {
 			require.Equal(t, "1.2.4-alpha.1", v.String())
 		})
 		t.Run("all meta", func(t *testing.T) {
-			v, err := nextVersion(semver.MustParse("1.2.3"), "v1.2.3", Options{
+			v, _, err := nextVersion(semver.MustParse("1.2.3"), "v1.2.3", Options{
 				Ctx:        t.Context(),
 				Action:     Patch,
 				Metadata:   "125",
@@ -245,7 +268,7 @@ func TestCmd(t *testing.T) {
 
 	t.Run("major", func(t *testing.T) {
 		t.Run("no meta", func(t *testing.T) {
-			v, err := nextVersion(ver(), "v1.2.3", Options{
+			v, _, err := nextVersion(ver(), "v1.2.3", Options{
 				Ctx:    t.Context(),
 				Action: Major,
 			})
@@ -253,7 +276,7 @@ func TestCmd(t *testing.T) {
 			require.Equal(t, "2.0.0", v.String())
 		})
 		t.Run("metadata", func(t *testing.T) {
-			v, err := nextVersion(ver(), "v1.2.3", Options{
+			v, _, err := nextVersion(ver(), "v1.2.3", Options{
 				Ctx:      t.Context(),
 				Action:   Major,
 				Metadata: "124",
@@ -262,7 +285,7 @@ func TestCmd(t *testing.T) {
 			require.Equal(t, "2.0.0+124", v.String())
 		})
 		t.Run("prerelease", func(t *testing.T) {
-			v, err := nextVersion(ver(), "v1.2.3", Options{
+			v, _, err := nextVersion(ver(), "v1.2.3", Options{
 				Ctx:        t.Context(),
 				Action:     Major,
 				PreRelease: "alpha.1",
@@ -271,7 +294,7 @@ func TestCmd(t *testing.T) {
 			require.Equal(t, "2.0.0-alpha.1", v.String())
 		})
 		t.Run("all meta", func(t *testing.T) {
-			v, err := nextVersion(ver(), "v1.2.3", Options{
+			v, _, err := nextVersion(ver(), "v1.2.3", Options{
 				Ctx:        t.Context(),
 				Action:     Major,
 				PreRelease: "alpha.2",
@@ -284,11 +307,11 @@ func TestCmd(t *testing.T) {
 
 	t.Run("errors", func(t *testing.T) {
 		t.Run("invalid build", func(t *testing.T) {
-			_, err := nextVersion(semver.MustParse("1.2.3"), "v1.2.3", Options{Ctx: t.Context()})
+			_, _, err := nextVersion(semver.MustParse("1.2.3"), "v1.2.3", Options{Ctx: t.Context()})
 			require.Error(t, err)
 		})
 		t.Run("invalid prerelease", func(t *testing.T) {
-			_, err := nextVersion(semver.MustParse("1.2.3"), "v1.2.3", Options{Ctx: t.Context()})
+			_, _, err := nextVersion(semver.MustParse("1.2.3"), "v1.2.3", Options{Ctx: t.Context()})
 			require.Error(t, err)
 		})
 	})
@@ -412,3 +435,133 @@ func Test_nextPreRelease(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatOutput(t *testing.T) {
+	repo := svutest.New()
+	repo.Commit("chore: init")
+	repo.Tag("v1.2.2")
+	repo.Commit("feat: foo")
+	head := repo.Commit("fix: bar")
+	opts := Options{Ctx: t.Context(), Repository: repo}
+	info := VersionInfo{
+		Version:     "v1.2.3-beta.4+abc",
+		Prefix:      "v",
+		Major:       1,
+		Minor:       2,
+		Patch:       3,
+		Prerelease:  "beta",
+		Build:       "4",
+		Metadata:    "abc",
+		PreviousTag: "v1.2.2",
+	}
+	format := func(format string) (string, error) {
+		opts.Format = format
+		return formatOutput(info, opts)
+	}
+
+	out, err := format("{{.Major}}.{{.Minor}}")
+	require.NoError(t, err)
+	require.Equal(t, "1.2", out)
+
+	out, err = format("{{.Prefix}}{{.Patch}}-{{.Prerelease}}.{{.Build}}+{{.Metadata}} {{.PreviousTag}} {{.Commit}} {{.CommitsSinceTag}}")
+	require.NoError(t, err)
+	require.Equal(t, "v3-beta.4+abc v1.2.2 "+head+" 2", out)
+
+	_, err = format("{{.Major")
+	require.Error(t, err)
+
+	_, err = format("{{.Nope}}")
+	require.Error(t, err)
+
+	t.Run("no commits", func(t *testing.T) {
+		opts.Repository = svutest.New()
+		info.PreviousTag = ""
+		out, err := format("{{.Version}} {{.Commit}}{{.CommitsSinceTag}}")
+		require.NoError(t, err)
+		require.Equal(t, "v1.2.3-beta.4+abc 0", out)
+
+		opts.Action = Current
+		opts.Format = ""
+		opts.JSON = true
+		out, err = Version(opts)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"version":"0.0.0","major":0,"minor":0,"patch":0}`, out)
+	})
+}
+
+func TestVersion(t *testing.T) {
+	repo := svutest.New()
+	repo.Commit("chore: init", "README.md")
+	repo.Tag("v1.2.3")
+	repo.TagUnmerged("v1.4.0")
+	repo.Commit("fix: foo", "lib/foo.go")
+	repo.Commit("feat: bar", "cmd/bar.go")
+
+	opts := Options{
+		Ctx:        t.Context(),
+		Prefix:     "v",
+		TagMode:    git.TagModeCurrent,
+		Repository: repo,
+	}
+
+	for expected, action := range map[string]Action{
+		"v1.3.0": Next,
+		"v1.2.3": Current,
+		"v2.0.0": Major,
+		"v1.2.4": Patch,
+	} {
+		t.Run(expected, func(t *testing.T) {
+			opts := opts
+			opts.Action = action
+			v, err := Version(opts)
+			require.NoError(t, err)
+			require.Equal(t, expected, v)
+		})
+	}
+
+	t.Run("all branches", func(t *testing.T) {
+		opts := opts
+		opts.Action = Current
+		opts.TagMode = git.TagModeAll
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.4.0", v)
+	})
+
+	t.Run("directories", func(t *testing.T) {
+		opts := opts
+		opts.Directories = []string{"lib"}
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.2.4", v)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		opts := opts
+		opts.JSON = true
+		v, err := Version(opts)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"version":"v1.3.0","major":1,"minor":3,"patch":0,"prefix":"v","previous_tag":"v1.2.3"}`, v)
+	})
+
+	t.Run("tag", func(t *testing.T) {
+		repo.AddRemote("origin")
+		opts := opts
+		opts.Annotate = true
+		opts.Push = true
+		opts.Remote = "origin"
+		tag, err := Tag(opts)
+		require.NoError(t, err)
+		require.Equal(t, "v1.3.0", tag)
+
+		msg, signed := repo.TagMessage(tag)
+		require.Equal(t, "v1.3.0\n\n- feat: bar\n- fix: foo", msg)
+		require.False(t, signed)
+		exists, err := repo.RemoteTagExists(t.Context(), "origin", tag)
+		require.NoError(t, err)
+		require.True(t, exists)
+
+		_, err = Tag(opts)
+		require.EqualError(t, err, "tag v1.3.0 already exists")
+	})
+}
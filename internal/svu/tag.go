@@ -0,0 +1,119 @@
+package svu
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+)
+
+// DefaultTagMessage is the template used for the message of annotated and
+// signed tags when none is given.
+const DefaultTagMessage = `{{ .Version }}
+{{ range .Commits }}
+- {{ .Title }}{{ end }}
+`
+
+type tagMessageData struct {
+	Version  string
+	Previous string
+	Commits  []git.Commit
+}
+
+// Tag creates a git tag for the version computed from the given options, and
+// returns its name.
+//
+// The tag is annotated if Annotate or Sign are set, or if a TagMessage is
+// given, in which case TagMessage is used as the template of its message.
+// If Push is set, the tag is then pushed to Remote, and if VerifyRemote is set,
+// the remote is checked not to have the tag before anything is created.
+// If DryRun is set, nothing is created nor pushed.
+func Tag(opts Options) (string, error) {
+	previous, version, err := nextTag(opts)
+	if err != nil {
+		return "", err
+	}
+	name := opts.Prefix + version
+
+	message, err := prepareTag(name, previous, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.DryRun {
+		log.Printf("dry-run: would create tag %s with message: %q\n", name, message)
+		if opts.Push {
+			log.Printf("dry-run: would push tag %s to %s\n", name, opts.Remote)
+		}
+		return name, nil
+	}
+
+	if err := repository(opts).CreateTag(opts.Ctx, name, message, opts.Sign); err != nil {
+		return "", fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+	log.Printf("created tag %s\n", name)
+
+	if opts.Push {
+		if err := repository(opts).PushTag(opts.Ctx, opts.Remote, name); err != nil {
+			return "", fmt.Errorf("tag %s was created, but could not be pushed to %s: %w", name, opts.Remote, err)
+		}
+		log.Printf("pushed tag %s to %s\n", name, opts.Remote)
+	}
+	return name, nil
+}
+
+// prepareTag checks the given tag can be created, and returns its message.
+func prepareTag(name, previous string, opts Options) (string, error) {
+	exists, err := repository(opts).TagExists(opts.Ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to check if tag exists: %w", err)
+	}
+	if exists {
+		return "", fmt.Errorf("tag %s already exists", name)
+	}
+
+	if opts.VerifyRemote {
+		exists, err := repository(opts).RemoteTagExists(opts.Ctx, opts.Remote, name)
+		if err != nil {
+			return "", fmt.Errorf("failed to check if tag exists in %s: %w", opts.Remote, err)
+		}
+		if exists {
+			return "", fmt.Errorf("tag %s already exists in %s", name, opts.Remote)
+		}
+	}
+
+	if opts.Annotate || opts.Sign || opts.TagMessage != "" {
+		tmpl := opts.TagMessage
+		if tmpl == "" {
+			tmpl = DefaultTagMessage
+		}
+		return renderMessage("tag message", tmpl, name, previous, opts)
+	}
+	return "", nil
+}
+
+// renderMessage renders the given template of a tag or commit message, with
+// the commits since the previous tag.
+func renderMessage(kind, tmpl, name, previous string, opts Options) (string, error) {
+	t, err := template.New("message").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", kind, err)
+	}
+
+	commits, err := repository(opts).Changelog(opts.Ctx, previous, opts.Directories)
+	if err != nil {
+		return "", fmt.Errorf("failed to get changelog: %w", err)
+	}
+
+	var sb strings.Builder
+	if err := t.Execute(&sb, tagMessageData{
+		Version:  name,
+		Previous: previous,
+		Commits:  commits,
+	}); err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", kind, err)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -28,11 +29,25 @@ var examples []byte
 func main() {
 	var verbose bool
 	var configFile string
+	var backend string
+	var configLoaded bool
 	var opts svu.Options
+	var component string
+	var all bool
+	var components []svu.Component
+	var files []svu.File
+	var write, check bool
+	var prePush bool
 
 	runFunc := func(cmd *cobra.Command) error {
 		opts.Ctx = cmd.Context()
-		version, err := svu.Version(opts)
+		var version string
+		var err error
+		if all {
+			version, err = svu.Components(opts, components)
+		} else {
+			version, err = svu.Version(opts)
+		}
 		if err != nil {
 			return err
 		}
@@ -47,7 +62,17 @@ func main() {
 		Version:      buildVersion(version, commit, date, builtBy).String(),
 		Example:      paddingLeft(string(examples)),
 		SilenceUsage: true,
-		PersistentPreRunE: func(*cobra.Command, []string) error {
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
+			if configLoaded {
+				presetRequiredFlags(cmd)
+			}
+			if verbose {
+				log.SetFlags(0)
+			} else {
+				log.SetOutput(io.Discard)
+			}
+			opts.Ctx = cmd.Context()
+
 			switch opts.TagMode {
 			case git.TagModeAll, git.TagModeCurrent:
 			default:
@@ -59,15 +84,94 @@ func main() {
 				)
 			}
 
+			switch opts.TagSort {
+			case "", git.TagSortSemver, git.TagSortDate, git.TagSortTopo:
+			default:
+				return fmt.Errorf(
+					"invalid tag.sort: %q: valid options are %q, %q and %q",
+					opts.TagSort,
+					git.TagSortSemver,
+					git.TagSortDate,
+					git.TagSortTopo,
+				)
+			}
+
+			if err := viper.UnmarshalKey("rules", &opts.Rules); err != nil {
+				return fmt.Errorf("invalid rules: %w", err)
+			}
+
+			if err := viper.UnmarshalKey("components", &components); err != nil {
+				return fmt.Errorf("invalid components: %w", err)
+			}
+			if component != "" {
+				c, err := svu.FindComponent(components, component)
+				if err != nil {
+					return err
+				}
+				opts = c.Options(opts)
+			}
+
+			if err := viper.UnmarshalKey("files", &files); err != nil {
+				return fmt.Errorf("invalid files: %w", err)
+			}
+
+			switch backend {
+			case git.BackendExec:
+			case git.BackendNative:
+				repo, err := git.NewNative(".")
+				if err != nil {
+					return err
+				}
+				opts.Repository = repo
+			default:
+				return fmt.Errorf(
+					"invalid git.backend: %q: valid options are %q and %q",
+					backend,
+					git.BackendExec,
+					git.BackendNative,
+				)
+			}
+
+			var branches []svu.Branch
+			if err := viper.UnmarshalKey("branches", &branches); err != nil {
+				return fmt.Errorf("invalid branches: %w", err)
+			}
+			branch, ok, err := svu.FindBranch(opts, branches)
+			if err != nil {
+				return err
+			}
+			if ok {
+				log.Printf("using branch policy %q", branch.Match)
+				opts = branch.Options(opts)
+			}
+
+			switch opts.Scheme {
+			case svu.SchemeSemver, svu.SchemeCalver:
+			default:
+				return fmt.Errorf(
+					"invalid scheme: %q: valid options are %q and %q",
+					opts.Scheme,
+					svu.SchemeSemver,
+					svu.SchemeCalver,
+				)
+			}
+
+			switch opts.GoMajorCheck {
+			case "", svu.GoMajorCheckError, svu.GoMajorCheckWarn, svu.GoMajorCheckOff:
+			default:
+				return fmt.Errorf(
+					"invalid go.major_check: %q: valid options are %q, %q and %q",
+					opts.GoMajorCheck,
+					svu.GoMajorCheckError,
+					svu.GoMajorCheckWarn,
+					svu.GoMajorCheckOff,
+				)
+			}
+
 			if opts.PrefixOutput == "^tag.prefix^" {
 				opts.PrefixOutput = opts.Prefix
 			}
 
-			if verbose {
-				log.SetFlags(0)
-			} else {
-				log.SetOutput(io.Discard)
-			}
 			return nil
 		},
 	}
@@ -90,6 +194,16 @@ func main() {
 			return runFunc(cmd)
 		},
 	}
+	explainCmd := &cobra.Command{
+		Use:     "explain",
+		Aliases: []string{"e"},
+		Short:   "Explains how the next version is computed",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts.Action = svu.Next
+			opts.Explain = true
+			return runFunc(cmd)
+		},
+	}
 	majorCmd := &cobra.Command{
 		Use:   "major",
 		Short: "New major release",
@@ -125,6 +239,190 @@ func main() {
 			return runFunc(cmd)
 		},
 	}
+	promoteCmd := &cobra.Command{
+		Use:   "promote",
+		Short: "Final version of the current prerelease",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts.Action = svu.Promote
+			return runFunc(cmd)
+		},
+	}
+	tagCmd := &cobra.Command{
+		Use:     "tag",
+		Aliases: []string{"t"},
+		Short:   "Creates a git tag with the next version",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts.Ctx = cmd.Context()
+			opts.Action = svu.Next
+			tag, err := svu.Tag(opts)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), tag)
+			return err
+		},
+	}
+	changelogCmd := &cobra.Command{
+		Use:     "changelog",
+		Aliases: []string{"cl"},
+		Short:   "Release notes of the next version, in Markdown",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts.Ctx = cmd.Context()
+			opts.Action = svu.Next
+			changelog, err := svu.Changelog(opts)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprint(cmd.OutOrStdout(), changelog)
+			return err
+		},
+	}
+	bumpCmd := &cobra.Command{
+		Use:   "bump",
+		Short: "Writes the next version in the configured files, or checks them",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts.Ctx = cmd.Context()
+			if len(files) == 0 {
+				return errors.New("no files configured")
+			}
+			var version string
+			var err error
+			if check {
+				opts.Action = svu.Current
+				version, err = svu.CheckFiles(opts, files)
+			} else {
+				opts.Action = svu.Next
+				version, err = svu.WriteFiles(opts, files)
+			}
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), version)
+			return err
+		},
+	}
+	releaseCmd := &cobra.Command{
+		Use:   "release",
+		Short: "Writes the next version in the configured files, commits and tags them",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts.Ctx = cmd.Context()
+			opts.Action = svu.Next
+			tag, err := svu.Release(opts, files)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), tag)
+			return err
+		},
+	}
+	lintCmd := &cobra.Command{
+		Use:   "lint [file]",
+		Short: "Checks the commits since the current tag, or the commit message in the given file, follow the conventions",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Ctx = cmd.Context()
+			var result svu.LintResult
+			var err error
+			switch {
+			case len(args) == 0:
+				result, err = svu.Lint(opts)
+			case args[0] == "-":
+				var bts []byte
+				bts, err = io.ReadAll(cmd.InOrStdin())
+				if err == nil {
+					result, err = svu.LintMessage(opts, string(bts))
+				}
+			default:
+				var bts []byte
+				bts, err = os.ReadFile(args[0])
+				if err == nil {
+					result, err = svu.LintMessage(opts, string(bts))
+				}
+			}
+			if err != nil {
+				return err
+			}
+			out := result.String()
+			if opts.JSON {
+				out, err = result.JSON()
+				if err != nil {
+					return err
+				}
+			}
+			if out != "" {
+				if _, err := fmt.Fprintln(cmd.OutOrStdout(), out); err != nil {
+					return err
+				}
+			}
+			if len(result.Problems) > 0 {
+				return errors.New("commit messages do not follow the conventions")
+			}
+			return nil
+		},
+	}
+	hooksCmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "Manages the git hooks that lint commit messages and verify pushed tags",
+	}
+	hooksInstallCmd := &cobra.Command{
+		Use:   "install",
+		Short: "Installs the commit-msg hook, and optionally the pre-push hook",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts.Ctx = cmd.Context()
+			hooks := []string{svu.HookCommitMsg}
+			if prePush {
+				hooks = append(hooks, svu.HookPrePush)
+			}
+			paths, err := svu.InstallHooks(opts, hooks)
+			for _, p := range paths {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), p)
+			}
+			return err
+		},
+	}
+	hooksUninstallCmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Removes the hooks installed by svu",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts.Ctx = cmd.Context()
+			paths, err := svu.UninstallHooks(opts)
+			for _, p := range paths {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), p)
+			}
+			return err
+		},
+	}
+	hooksPrePushCmd := &cobra.Command{
+		Use:    "pre-push",
+		Short:  "Verifies the tags being pushed match the next version, run by the pre-push hook",
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts.Ctx = cmd.Context()
+			tags, err := svu.PushedTags(cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			return svu.VerifyTags(opts, tags)
+		},
+	}
+	goCmd := &cobra.Command{
+		Use:   "go",
+		Short: "Next version of every Go module in the repository",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts.Ctx = cmd.Context()
+			opts.Action = svu.Next
+			modules, err := svu.GoModules(opts)
+			if err != nil {
+				return err
+			}
+			version, err := svu.Components(opts, modules)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), version)
+			return err
+		},
+	}
 	initCmd := &cobra.Command{
 		Use:     "init",
 		Short:   "Creates a svu configuration file",
@@ -137,10 +435,34 @@ func main() {
 	rootCmd.SetVersionTemplate("{{.Version}}")
 	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "enable logs")
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", ".svu.yml", "set config file")
+	rootCmd.PersistentFlags().StringVar(&backend, "git.backend", git.BackendExec, "how to read the repository: exec, using the git binary, or native")
+	rootCmd.PersistentFlags().BoolVar(&opts.AutoFetch, "git.auto_fetch", false, "deepen shallow clones until the current tag is found")
+	rootCmd.PersistentFlags().StringVar(&opts.Baseline, "git.baseline", "", "version to start from in shallow clones where the current tag is not found")
 	rootCmd.AddCommand(initCmd)
-	nextCmd.Flags().BoolVar(&opts.Always, "always", false, "if no commits trigger a version change, increment the patch")
-	nextCmd.Flags().BoolVar(&opts.KeepV0, "v0", false, "prevent major version increments if current version is still v0")
+	for _, cmd := range []*cobra.Command{
+		nextCmd,
+		explainCmd,
+		tagCmd,
+		changelogCmd,
+		goCmd,
+		bumpCmd,
+		releaseCmd,
+		hooksPrePushCmd,
+	} {
+		cmd.Flags().BoolVar(&opts.Always, "always", false, "if no commits trigger a version change, increment the patch")
+		cmd.Flags().BoolVar(&opts.KeepV0, "v0", false, "prevent major version increments if current version is still v0")
+	}
 
+	explainCmd.Flags().BoolVar(&opts.JSON, "json", false, "output explanation as json")
+	goCmd.Flags().BoolVar(&opts.JSON, "json", false, "output versions as json")
+	goCmd.Flags().StringVar(&opts.Format, "format", "", "output every version using the given Go template")
+	goCmd.MarkFlagsMutuallyExclusive("json", "format")
+	goCmd.Flags().BoolVar(&opts.Explain, "explain", false, "explain how the versions were computed instead of printing them")
+	goCmd.Flags().StringVar(&opts.TagMode, "tag.mode", git.TagModeAll, "determine if it should look for tags in all branches, or just the current one")
+	goCmd.Flags().StringVar(&opts.TagSort, "tag.sort", git.TagSortSemver, "how to pick the current tag: semver, the highest version, date, the newest tag, or topo, the nearest tag")
+	goCmd.Flags().StringVar(&opts.PreRelease, "prerelease", "", "sets the version prerelease")
+	goCmd.Flags().StringVar(&opts.Metadata, "metadata", "", "sets the version metadata, optionally using a Go template with git information")
+	rootCmd.AddCommand(goCmd)
 	for _, cmd := range []*cobra.Command{
 		nextCmd,
 		majorCmd,
@@ -148,24 +470,162 @@ func main() {
 		patchCmd,
 		currentCmd,
 		prereleaseCmd,
+		promoteCmd,
 	} {
-		// init does not share these flags.
 		cmd.Flags().BoolVar(&opts.JSON, "json", false, "output version as json")
+		cmd.Flags().StringVar(&opts.Format, "format", "", "output version using the given Go template")
+		cmd.MarkFlagsMutuallyExclusive("json", "format")
+		cmd.Flags().StringVar(&opts.PrefixOutput, "tag.output", "^tag.prefix^", "set the tag output to use when printing the version (default: 'tag.prefix')")
+	}
+
+	for _, cmd := range []*cobra.Command{
+		nextCmd,
+		explainCmd,
+		majorCmd,
+		minorCmd,
+		patchCmd,
+		currentCmd,
+		prereleaseCmd,
+		promoteCmd,
+		tagCmd,
+		changelogCmd,
+		bumpCmd,
+		releaseCmd,
+	} {
+		// init does not share these flags.
 		cmd.Flags().StringVar(&opts.Pattern, "tag.pattern", "", "ignore tags that do not match the given pattern")
 		cmd.Flags().StringVar(&opts.Prefix, "tag.prefix", "v", "sets a tag custom prefix")
-		cmd.Flags().StringVar(&opts.PrefixOutput, "tag.output", "^tag.prefix^", "set the tag output to use when printing the version (default: 'tag.prefix')")
 		cmd.Flags().StringVar(&opts.TagMode, "tag.mode", git.TagModeAll, "determine if it should look for tags in all branches, or just the current one")
-		cmd.Flags().StringVar(&opts.PreRelease, "prerelease", "", "sets the version prerelease")
-		cmd.Flags().StringVar(&opts.Metadata, "metadata", "", "sets the version metadata")
+		cmd.Flags().StringVar(&opts.TagSort, "tag.sort", git.TagSortSemver, "how to pick the current tag: semver, the highest version, date, the newest tag, or topo, the nearest tag")
+		cmd.Flags().StringVar(&opts.Metadata, "metadata", "", "sets the version metadata, optionally using a Go template with git information")
+		cmd.Flags().StringVar(&component, "component", "", "use the tag prefix, tag pattern and log directories of the given component")
+		cmd.Flags().StringVar(&opts.Scheme, "scheme", svu.SchemeSemver, "versioning scheme to use: semver or calver")
+		cmd.Flags().StringVar(&opts.CalverFormat, "calver.format", svu.DefaultCalverFormat, "calendar version format, using YYYY, YY, 0Y, MM, 0M, WW, 0W, DD, 0D and MICRO")
 		rootCmd.AddCommand(cmd)
 	}
 
 	for _, cmd := range []*cobra.Command{
 		nextCmd,
+		explainCmd,
+		majorCmd,
+		minorCmd,
+		patchCmd,
+		currentCmd,
+		prereleaseCmd,
+		tagCmd,
+		changelogCmd,
+		bumpCmd,
+		releaseCmd,
+	} {
+		// promote always removes the prerelease.
+		cmd.Flags().StringVar(&opts.PreRelease, "prerelease", "", "sets the version prerelease")
+	}
+
+	for _, cmd := range []*cobra.Command{
+		nextCmd,
+		explainCmd,
 		prereleaseCmd,
+		tagCmd,
+		changelogCmd,
+		bumpCmd,
+		releaseCmd,
+		lintCmd,
+		hooksPrePushCmd,
 	} {
 		cmd.Flags().StringSliceVar(&opts.Directories, "log.directory", nil, "only use commits that changed files in the given directories")
 	}
+
+	for _, cmd := range []*cobra.Command{
+		nextCmd,
+		currentCmd,
+		explainCmd,
+	} {
+		cmd.Flags().BoolVar(&all, "all", false, "output the version of every component")
+		cmd.MarkFlagsMutuallyExclusive("all", "component")
+	}
+
+	promoteCmd.Flags().StringVar(&opts.PromoteVerify, "promote.verify", svu.VerifyNone, "what to verify before promoting: none, same_commit, HEAD is the prerelease, or no_bump, no commits since it bump the version")
+	promoteCmd.Flags().StringSliceVar(&opts.Directories, "log.directory", nil, "only use commits that changed files in the given directories")
+
+	prereleaseCmd.Flags().BoolVar(&opts.PreReleaseFromBranch, "prerelease.from_branch", false, "derive the prerelease from the current branch when none is given")
+	prereleaseCmd.Flags().IntVar(&opts.PreReleaseMaxLength, "prerelease.max_length", 0, "maximum length of the prerelease derived from the branch, 0 means no limit")
+	prereleaseCmd.Flags().StringVar(&opts.PreReleaseTemplate, "prerelease.template", svu.DefaultPreReleaseTemplate, "template of the prerelease derived from the branch")
+
+	for _, cmd := range []*cobra.Command{
+		nextCmd,
+		prereleaseCmd,
+	} {
+		cmd.Flags().BoolVar(&opts.Explain, "explain", false, "explain how the version was computed instead of printing it")
+	}
+
+	for _, cmd := range []*cobra.Command{
+		nextCmd,
+		explainCmd,
+		majorCmd,
+		prereleaseCmd,
+		tagCmd,
+		goCmd,
+		bumpCmd,
+		releaseCmd,
+	} {
+		cmd.Flags().StringVar(&opts.GoMajorCheck, "go.major_check", svu.GoMajorCheckError, "what to do when a major bump does not match the go module path: error, warn or off")
+	}
+
+	for _, cmd := range []*cobra.Command{
+		nextCmd,
+		explainCmd,
+		prereleaseCmd,
+		tagCmd,
+		changelogCmd,
+		goCmd,
+		bumpCmd,
+		releaseCmd,
+		hooksPrePushCmd,
+	} {
+		cmd.Flags().BoolVar(&opts.DetectGoAPI, "detect.go_api", false, "bump major when the exported go api changed, and minor when it grew")
+	}
+
+	lintCmd.Flags().BoolVar(&opts.JSON, "json", false, "output the problems as json")
+	lintCmd.Flags().StringSliceVar(&opts.LintTypes, "lint.types", svu.DefaultLintTypes, "conventional commit types allowed, in addition to the types of the rules")
+	rootCmd.AddCommand(lintCmd)
+
+	hooksInstallCmd.Flags().BoolVar(&prePush, "pre-push", false, "also install the pre-push hook, verifying the tags being pushed match the next version")
+	hooksCmd.AddCommand(hooksInstallCmd, hooksUninstallCmd, hooksPrePushCmd)
+	rootCmd.AddCommand(hooksCmd)
+
+	for _, cmd := range []*cobra.Command{
+		lintCmd,
+		hooksPrePushCmd,
+	} {
+		cmd.Flags().StringVar(&opts.Pattern, "tag.pattern", "", "ignore tags that do not match the given pattern")
+		cmd.Flags().StringVar(&opts.Prefix, "tag.prefix", "v", "sets a tag custom prefix")
+		cmd.Flags().StringVar(&opts.TagMode, "tag.mode", git.TagModeAll, "determine if it should look for tags in all branches, or just the current one")
+		cmd.Flags().StringVar(&opts.TagSort, "tag.sort", git.TagSortSemver, "how to pick the current tag: semver, the highest version, date, the newest tag, or topo, the nearest tag")
+		cmd.Flags().StringVar(&component, "component", "", "use the tag prefix, tag pattern and log directories of the given component")
+	}
+
+	bumpCmd.Flags().BoolVar(&write, "write", false, "write the next version in the files")
+	bumpCmd.Flags().BoolVar(&check, "check", false, "fail if the files do not have the current version")
+	bumpCmd.MarkFlagsOneRequired("write", "check")
+	bumpCmd.MarkFlagsMutuallyExclusive("write", "check")
+
+	for _, cmd := range []*cobra.Command{
+		tagCmd,
+		releaseCmd,
+	} {
+		cmd.Flags().BoolVar(&opts.Annotate, "tag.annotate", false, "create an annotated tag")
+		cmd.Flags().BoolVar(&opts.Sign, "tag.sign", false, "sign the tag using git's signing configuration")
+		cmd.Flags().StringVar(&opts.TagMessage, "tag.message", "", "template of the tag message, implies --tag.annotate")
+	}
+	// release never pushes, so it does not use remotes.
+	tagCmd.Flags().BoolVar(&opts.Push, "tag.push", false, "push the tag to the remote after creating it")
+	tagCmd.Flags().StringVar(&opts.Remote, "tag.remote", "origin", "remote to push the tag to")
+	tagCmd.Flags().BoolVar(&opts.VerifyRemote, "tag.verify_remote", false, "check that the remote does not have the tag before creating it")
+	tagCmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "do not create nor push the tag, only print it")
+
+	releaseCmd.Flags().StringVar(&opts.ReleaseMessage, "release.message", svu.DefaultReleaseMessage, "template of the release commit message")
+	releaseCmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "do not write, commit nor tag anything, only print the tag")
+
 	cobra.OnInitialize(func() {
 		home, _ := os.UserHomeDir()
 		config, _ := os.UserConfigDir()
@@ -180,9 +640,7 @@ func main() {
 		viper.AddConfigPath(home)
 		viper.SetConfigType("yaml")
 		viper.SetConfigName(configFile)
-		if viper.ReadInConfig() == nil {
-			presetRequiredFlags(rootCmd)
-		}
+		configLoaded = viper.ReadInConfig() == nil
 	})
 
 	if err := fang.Execute(
@@ -195,24 +653,27 @@ func main() {
 	}
 }
 
+// presetRequiredFlags sets the flags of the given command not given in the
+// command line from the config.
+// Only the flags of the command being run are set, as flags of different
+// commands share the same variables.
+// Flags are not bound, as a bound flag, e.g. prerelease, would shadow the
+// config keys nested in it, e.g. prerelease.from_branch.
 func presetRequiredFlags(cmd *cobra.Command) {
-	_ = viper.BindPFlags(cmd.Flags())
 	cmd.Flags().VisitAll(func(f *pflag.Flag) {
-		if viper.IsSet(f.Name) {
-			switch f.Name {
-			case "log.directory":
-				dirs := viper.GetStringSlice(f.Name)
-				for _, dir := range dirs {
-					_ = cmd.Flags().Set(f.Name, dir)
-				}
-			default:
-				_ = cmd.Flags().Set(f.Name, viper.GetString(f.Name))
+		if f.Changed || !viper.IsSet(f.Name) {
+			return
+		}
+		switch f.Name {
+		case "log.directory", "lint.types":
+			values := viper.GetStringSlice(f.Name)
+			for _, value := range values {
+				_ = cmd.Flags().Set(f.Name, value)
 			}
+		default:
+			_ = cmd.Flags().Set(f.Name, viper.GetString(f.Name))
 		}
 	})
-	for _, scmd := range cmd.Commands() {
-		presetRequiredFlags(scmd)
-	}
 }
 
 //nolint:gochecknoglobals
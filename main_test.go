@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresetRequiredFlags(t *testing.T) {
+	setup := func(tb testing.TB, config string) (next, pre *cobra.Command, prerelease *string, fromBranch *bool) {
+		tb.Helper()
+		viper.Reset()
+		tb.Cleanup(viper.Reset)
+		viper.SetConfigType("yaml")
+		require.NoError(tb, viper.ReadConfig(strings.NewReader(config)))
+
+		// like in main, flags of different commands share the same variables.
+		prerelease, fromBranch = new(string), new(bool)
+		next = &cobra.Command{Use: "next"}
+		next.Flags().StringVar(prerelease, "prerelease", "", "")
+		pre = &cobra.Command{Use: "prerelease"}
+		pre.Flags().StringVar(prerelease, "prerelease", "", "")
+		pre.Flags().BoolVar(fromBranch, "prerelease.from_branch", false, "")
+		root := &cobra.Command{Use: "svu"}
+		root.AddCommand(next, pre)
+		return next, pre, prerelease, fromBranch
+	}
+
+	t.Run("config", func(t *testing.T) {
+		next, _, prerelease, _ := setup(t, "prerelease: beta\n")
+		presetRequiredFlags(next)
+		require.Equal(t, "beta", *prerelease)
+	})
+
+	t.Run("command line wins", func(t *testing.T) {
+		next, pre, prerelease, _ := setup(t, "prerelease: beta\n")
+		require.NoError(t, next.ParseFlags([]string{"--prerelease", "rc"}))
+		presetRequiredFlags(next)
+		require.Equal(t, "rc", *prerelease)
+		require.False(t, pre.Flags().Changed("prerelease"))
+	})
+
+	t.Run("nested in a flag", func(t *testing.T) {
+		_, pre, prerelease, fromBranch := setup(t, "prerelease.from_branch: true\n")
+		presetRequiredFlags(pre)
+		require.True(t, *fromBranch)
+		require.Empty(t, *prerelease)
+	})
+}
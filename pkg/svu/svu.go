@@ -3,6 +3,7 @@ package svu
 
 import (
 	"context"
+	"slices"
 
 	"github.com/caarlos0/svu/v3/internal/git"
 	"github.com/caarlos0/svu/v3/internal/svu"
@@ -10,6 +11,16 @@ import (
 
 type option func(o *svu.Options)
 
+// Rule classifies commits into a version bump.
+type Rule = svu.Rule
+
+// Repository is a git repository svu reads the history from, and creates tags
+// in.
+type Repository = git.Repository
+
+// Commit is a commit in a Repository.
+type Commit = git.Commit
+
 // Option is a functional option for configuring svu.
 type Option option
 
@@ -43,6 +54,22 @@ func PreRelease(opts ...Option) (string, error) {
 	return version(append(opts, cmd(svu.PreRelease))...)
 }
 
+// Promote returns the final version of the current prerelease, e.g. v1.3.0
+// for v1.3.0-rc.4. It fails if the final version is already tagged.
+func Promote(opts ...Option) (string, error) {
+	return version(append(opts, cmd(svu.Promote))...)
+}
+
+// Tag creates a git tag with the next version, and returns its name.
+func Tag(opts ...Option) (string, error) {
+	return svu.Tag(options(append(opts, cmd(svu.Next))...))
+}
+
+// Changelog returns the Markdown release notes of the next version.
+func Changelog(opts ...Option) (string, error) {
+	return svu.Changelog(options(append(opts, cmd(svu.Next))...))
+}
+
 // WithPattern ignores tags that do not match the given pattern.
 func WithPattern(pattern string) Option {
 	return func(o *svu.Options) {
@@ -64,13 +91,42 @@ func WithPreRelease(prerelease string) Option {
 	}
 }
 
+// WithPreReleaseFromBranch derives the prerelease of PreRelease from the
+// current branch, when none is given, using the given template, e.g.
+// "{{.Branch}}", and maximum length, zero meaning no limit.
+func WithPreReleaseFromBranch(template string, maxLength int) Option {
+	return func(o *svu.Options) {
+		o.PreReleaseFromBranch = true
+		o.PreReleaseTemplate = template
+		o.PreReleaseMaxLength = maxLength
+	}
+}
+
 // WithMetadata sets the version metadata.
+// It can be a Go template using .SHA, .ShortSHA, .CommitsSinceTag,
+// .CommitTimestamp, .Branch and .Dirty, e.g. "{{.ShortSHA}}".
 func WithMetadata(metadata string) Option {
 	return func(o *svu.Options) {
 		o.Metadata = metadata
 	}
 }
 
+// WithFormat formats the version using the given Go template.
+func WithFormat(format string) Option {
+	return func(o *svu.Options) {
+		o.Format = format
+	}
+}
+
+// WithCalver uses calendar versioning with the given format, e.g.
+// "YYYY.0M.MICRO".
+func WithCalver(format string) Option {
+	return func(o *svu.Options) {
+		o.Scheme = svu.SchemeCalver
+		o.CalverFormat = format
+	}
+}
+
 // WithDirectories only use commits that changed files in the given directories.
 func WithDirectories(directories ...string) Option {
 	return func(o *svu.Options) {
@@ -92,6 +148,55 @@ func ForAllBranches() Option {
 	}
 }
 
+// SortTagsByDate picks the newest tag as the current one, by creation date,
+// instead of the highest version.
+func SortTagsByDate() Option {
+	return func(o *svu.Options) {
+		o.TagSort = git.TagSortDate
+	}
+}
+
+// SortTagsByDistance picks the tag nearest to HEAD as the current one, like
+// git describe, instead of the highest version.
+func SortTagsByDistance() Option {
+	return func(o *svu.Options) {
+		o.TagSort = git.TagSortTopo
+	}
+}
+
+// WithMaxBump sets the biggest bump allowed: major, minor, patch or none.
+// If the history asks for a bigger bump, it fails, unless WarnOnExceed is
+// set.
+func WithMaxBump(bump string) Option {
+	return func(o *svu.Options) {
+		o.MaxBump = bump
+	}
+}
+
+// WarnOnExceed caps the bump to the one set by WithMaxBump, writing a warning,
+// instead of failing.
+func WarnOnExceed() Option {
+	return func(o *svu.Options) {
+		o.OnExceed = svu.ExceedWarn
+	}
+}
+
+// VerifySameCommit makes Promote fail if HEAD is not the commit the
+// prerelease tags.
+func VerifySameCommit() Option {
+	return func(o *svu.Options) {
+		o.PromoteVerify = svu.VerifySameCommit
+	}
+}
+
+// VerifyNoBump makes Promote fail if any commit since the prerelease would
+// bump the version.
+func VerifyNoBump() Option {
+	return func(o *svu.Options) {
+		o.PromoteVerify = svu.VerifyNoBump
+	}
+}
+
 // Always if no commits would have increased the version, increase the
 // patch portion anyway.
 func Always() Option {
@@ -107,17 +212,131 @@ func KeepV0() Option {
 	}
 }
 
+// WithRules sets the rules used to classify commits, in priority order.
+// They replace the default rules, which can be obtained with DefaultRules.
+func WithRules(rules ...Rule) Option {
+	return func(o *svu.Options) {
+		o.Rules = append(o.Rules, rules...)
+	}
+}
+
+// DefaultRules returns the rules used when none are given.
+func DefaultRules() []Rule {
+	return slices.Clone(svu.DefaultRules)
+}
+
+// WithTagMessage sets the template of the tag message used by Tag.
+// It implies Annotated.
+func WithTagMessage(message string) Option {
+	return func(o *svu.Options) {
+		o.TagMessage = message
+	}
+}
+
+// Annotated makes Tag create an annotated tag.
+func Annotated() Option {
+	return func(o *svu.Options) {
+		o.Annotate = true
+	}
+}
+
+// Signed makes Tag create a signed tag, using git's signing configuration.
+func Signed() Option {
+	return func(o *svu.Options) {
+		o.Sign = true
+	}
+}
+
+// Push makes Tag push the tag to the given remote after creating it.
+func Push(remote string) Option {
+	return func(o *svu.Options) {
+		o.Push = true
+		o.Remote = remote
+	}
+}
+
+// VerifyRemote makes Tag check that the remote does not have the tag before
+// creating it.
+func VerifyRemote() Option {
+	return func(o *svu.Options) {
+		o.VerifyRemote = true
+	}
+}
+
+// DryRun makes Tag not create nor push anything.
+func DryRun() Option {
+	return func(o *svu.Options) {
+		o.DryRun = true
+	}
+}
+
+// CheckGoMajor makes major version bumps fail if the path of the Go module
+// in the given directory, relative to the repository root, does not have the
+// matching major version suffix.
+func CheckGoMajor(directory string) Option {
+	return func(o *svu.Options) {
+		o.GoMajorCheck = svu.GoMajorCheckError
+		o.GoModule = directory
+	}
+}
+
+// DetectGoAPI makes the next version a major if the exported API of the Go
+// module at the root of the repository changed since the current version, or
+// a minor if it only grew.
+func DetectGoAPI() Option {
+	return func(o *svu.Options) {
+		o.DetectGoAPI = true
+	}
+}
+
+// OpenNative opens the repository containing the given directory, reading it
+// directly instead of using the git binary.
+func OpenNative(dir string) (Repository, error) {
+	return git.NewNative(dir)
+}
+
+// WithRepository sets the git repository to use, instead of the repository
+// in the current directory.
+func WithRepository(repo Repository) Option {
+	return func(o *svu.Options) {
+		o.Repository = repo
+	}
+}
+
+// WithAutoFetch deepens shallow clones until the current tag is found.
+func WithAutoFetch() Option {
+	return func(o *svu.Options) {
+		o.AutoFetch = true
+	}
+}
+
+// WithBaseline sets the version to start from in shallow clones where the
+// current tag is not found.
+func WithBaseline(version string) Option {
+	return func(o *svu.Options) {
+		o.Baseline = version
+	}
+}
+
 func version(opts ...Option) (string, error) {
+	return svu.Version(options(opts...))
+}
+
+func options(opts ...Option) svu.Options {
 	options := &svu.Options{
-		Ctx:     context.Background(),
-		Action:  svu.Next,
-		Prefix:  "v",
-		TagMode: git.TagModeCurrent,
+		Ctx:          context.Background(),
+		Action:       svu.Next,
+		Prefix:       "v",
+		TagMode:      git.TagModeCurrent,
+		TagSort:      git.TagSortSemver,
+		Remote:       "origin",
+		Scheme:       svu.SchemeSemver,
+		GoMajorCheck: svu.GoMajorCheckOff,
 	}
 	for _, opt := range opts {
 		option(opt)(options)
 	}
-	return svu.Version(*options)
+	return *options
 }
 
 func cmd(cmd svu.Action) Option {
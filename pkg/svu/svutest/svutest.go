@@ -0,0 +1,391 @@
+// Package svutest provides an in-memory git repository, so code using svu can
+// be tested without a git binary.
+package svutest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+)
+
+// Repository is an in-memory git repository with a linear history.
+//
+// Use Commit and Tag to build its history, then pass it to svu with
+// svu.WithRepository.
+type Repository struct {
+	// Dir is the path returned by Root.
+	Dir string
+	// Branch is the name returned by CurrentBranch. Empty means HEAD is
+	// detached.
+	Branch string
+	// Dirty is returned by IsDirty.
+	Dirty bool
+
+	commits []commit
+	tags    map[string]tag
+	remotes map[string]map[string]bool
+	count   int
+	// staged are the files staged by Add, committed by CreateCommit.
+	staged []string
+	// depth is the number of commits available in a shallow clone, or zero
+	// if the whole history is.
+	depth int
+	// allTags is set if every tag is available in a shallow clone, even if
+	// its history is not.
+	allTags bool
+}
+
+type commit struct {
+	git.Commit
+	files []string
+}
+
+type tag struct {
+	sha     string
+	message string
+	signed  bool
+	// reachable is the number of commits of the history that are reachable
+	// from the tag.
+	reachable int
+	merged    bool
+	// created is the order the tag was created in.
+	created int
+}
+
+var _ git.Repository = &Repository{}
+
+// New returns an empty repository.
+func New() *Repository {
+	return &Repository{
+		tags:    map[string]tag{},
+		remotes: map[string]map[string]bool{},
+	}
+}
+
+// Commit adds a commit with the given message, changing the given files, and
+// returns its SHA.
+// The first line of the message is the commit title, and the rest its body.
+func (r *Repository) Commit(message string, files ...string) string {
+	r.count++
+	sha := fmt.Sprintf("%040x", r.count)
+	title, body, _ := strings.Cut(message, "\n")
+	r.commits = append(r.commits, commit{
+		Commit: git.Commit{
+			SHA:   sha,
+			Title: title,
+			Body:  strings.TrimLeft(body, "\n"),
+		},
+		files: files,
+	})
+	return sha
+}
+
+// Tag creates a lightweight tag pointing to the last commit.
+func (r *Repository) Tag(name string) {
+	if len(r.commits) == 0 {
+		panic("svutest: cannot tag an empty repository")
+	}
+	r.tags[name] = tag{
+		sha:       r.commits[len(r.commits)-1].SHA,
+		reachable: len(r.commits),
+		merged:    true,
+		created:   len(r.tags) + 1,
+	}
+}
+
+// TagUnmerged creates a tag pointing to a commit in another branch, forked
+// from the last commit, so it is only found in TagModeAll.
+func (r *Repository) TagUnmerged(name string) {
+	r.count++
+	r.tags[name] = tag{
+		sha:       fmt.Sprintf("%040x", r.count),
+		reachable: len(r.commits),
+		created:   len(r.tags) + 1,
+	}
+}
+
+// TagMessage returns the message of the given tag, and whether it was signed.
+func (r *Repository) TagMessage(name string) (string, bool) {
+	t := r.tags[name]
+	return t.message, t.signed
+}
+
+// AddRemote adds a remote with the given name.
+func (r *Repository) AddRemote(name string) {
+	r.remotes[name] = map[string]bool{}
+}
+
+// Shallow makes the repository a shallow clone with only the last depth
+// commits, and the tags pointing to them, available, until it is deepened.
+func (r *Repository) Shallow(depth int) {
+	r.depth = depth
+	if r.depth >= len(r.commits) {
+		r.depth = 0
+	}
+}
+
+// FetchTags makes every tag available in a shallow clone, like
+// git fetch --tags, even if the commits they point to are not part of the
+// history available.
+func (r *Repository) FetchTags() {
+	r.allTags = true
+}
+
+// Root implements git.Repository.
+func (r *Repository) Root(context.Context) string {
+	return r.Dir
+}
+
+// ListTags implements git.Repository.
+//
+// Tags are created in order, so the date order is the order they were
+// created in.
+func (r *Repository) ListTags(_ context.Context, tagMode, tagSort string) ([]string, error) {
+	var tags []string
+	dates := map[string]int64{}
+	distances := map[string]int{}
+	for name, t := range r.tags {
+		if tagMode == git.TagModeCurrent && !t.merged || !r.fetched(t) {
+			continue
+		}
+		tags = append(tags, name)
+		dates[name] = int64(t.created)
+		if t.merged {
+			distances[name] = len(r.commits) - t.reachable
+		}
+	}
+	switch tagSort {
+	case git.TagSortDate:
+		git.SortTagsByDate(tags, dates)
+	case git.TagSortTopo:
+		git.SortTagsByDistance(tags, distances)
+	default:
+		git.SortTags(tags)
+	}
+	return tags, nil
+}
+
+// DescribeTag implements git.Repository.
+func (r *Repository) DescribeTag(ctx context.Context, tagMode, tagSort string, pattern string) (string, error) {
+	tags, err := r.ListTags(ctx, tagMode, tagSort)
+	if err != nil {
+		return "", err
+	}
+	return git.FindTag(tags, pattern)
+}
+
+// Changelog implements git.Repository.
+func (r *Repository) Changelog(_ context.Context, tag string, dirs []string) ([]git.Commit, error) {
+	commits, err := r.since(tag)
+	if err != nil {
+		return nil, err
+	}
+	var result []git.Commit
+	for i := len(commits) - 1; i >= 0; i-- {
+		if len(dirs) > 0 && !git.MatchPathspecs(commits[i].files, dirs) {
+			continue
+		}
+		result = append(result, commits[i].Commit)
+	}
+	return result, nil
+}
+
+// HeadSHA implements git.Repository.
+func (r *Repository) HeadSHA(context.Context) (string, error) {
+	if len(r.commits) == 0 {
+		return "", nil
+	}
+	return r.commits[len(r.commits)-1].SHA, nil
+}
+
+// HeadTime implements git.Repository.
+// Commits are made a minute apart, starting at the unix epoch.
+func (r *Repository) HeadTime(context.Context) (time.Time, error) {
+	if len(r.commits) == 0 {
+		return time.Time{}, errors.New("ambiguous argument 'HEAD': unknown revision")
+	}
+	return time.Unix(0, 0).UTC().Add(time.Duration(len(r.commits)) * time.Minute), nil
+}
+
+// IsDirty implements git.Repository.
+func (r *Repository) IsDirty(context.Context) (bool, error) {
+	return r.Dirty, nil
+}
+
+// CurrentBranch implements git.Repository.
+func (r *Repository) CurrentBranch(context.Context) (string, error) {
+	return r.Branch, nil
+}
+
+// CountCommits implements git.Repository.
+func (r *Repository) CountCommits(_ context.Context, tag string) (int, error) {
+	commits, err := r.since(tag)
+	return len(commits), err
+}
+
+// TagExists implements git.Repository.
+func (r *Repository) TagExists(_ context.Context, name string) (bool, error) {
+	_, ok := r.tags[name]
+	return ok, nil
+}
+
+// CreateTag implements git.Repository.
+func (r *Repository) CreateTag(_ context.Context, name, message string, sign bool) error {
+	if _, ok := r.tags[name]; ok {
+		return fmt.Errorf("tag '%s' already exists", name)
+	}
+	if len(r.commits) == 0 {
+		return errors.New("failed to resolve 'HEAD' as a valid ref")
+	}
+	r.Tag(name)
+	t := r.tags[name]
+	t.message = message
+	t.signed = sign
+	r.tags[name] = t
+	return nil
+}
+
+// DeleteTag implements git.Repository.
+func (r *Repository) DeleteTag(_ context.Context, name string) error {
+	if _, ok := r.tags[name]; !ok {
+		return fmt.Errorf("tag '%s' not found", name)
+	}
+	delete(r.tags, name)
+	return nil
+}
+
+// Add implements git.Repository.
+func (r *Repository) Add(_ context.Context, paths []string) error {
+	for _, p := range paths {
+		if !slices.Contains(r.staged, p) {
+			r.staged = append(r.staged, p)
+		}
+	}
+	return nil
+}
+
+// CreateCommit implements git.Repository.
+func (r *Repository) CreateCommit(_ context.Context, message string) error {
+	if len(r.staged) == 0 {
+		return errors.New("nothing to commit, working tree clean")
+	}
+	r.Commit(message, r.staged...)
+	r.staged = nil
+	return nil
+}
+
+// Reset implements git.Repository.
+func (r *Repository) Reset(_ context.Context, ref string) error {
+	i := slices.IndexFunc(r.commits, func(c commit) bool {
+		return c.SHA == ref
+	})
+	if i < 0 {
+		return fmt.Errorf("ambiguous argument '%s': unknown revision", ref)
+	}
+	r.commits = r.commits[:i+1]
+	r.staged = nil
+	return nil
+}
+
+// RemoteTagExists implements git.Repository.
+func (r *Repository) RemoteTagExists(_ context.Context, remote, name string) (bool, error) {
+	tags, ok := r.remotes[remote]
+	if !ok {
+		return false, fmt.Errorf("'%s' does not appear to be a git repository", remote)
+	}
+	return tags[name], nil
+}
+
+// PushTag implements git.Repository.
+func (r *Repository) PushTag(_ context.Context, remote, name string) error {
+	tags, ok := r.remotes[remote]
+	if !ok {
+		return fmt.Errorf("'%s' does not appear to be a git repository", remote)
+	}
+	if _, ok := r.tags[name]; !ok {
+		return fmt.Errorf("src refspec refs/tags/%s does not match any", name)
+	}
+	tags[name] = true
+	return nil
+}
+
+// AddWorktree implements git.Repository. It is not supported.
+func (r *Repository) AddWorktree(context.Context, string, string) error {
+	return errors.New("worktrees are not supported by the in-memory repository")
+}
+
+// RemoveWorktree implements git.Repository. It is not supported.
+func (r *Repository) RemoveWorktree(context.Context, string) error {
+	return errors.New("worktrees are not supported by the in-memory repository")
+}
+
+// HooksDir implements git.Repository. It is the hooks directory in Dir.
+func (r *Repository) HooksDir(context.Context) (string, error) {
+	if r.Dir == "" {
+		return "", errors.New("not a git repository")
+	}
+	return filepath.Join(r.Dir, ".git", "hooks"), nil
+}
+
+// IsShallow implements git.Repository.
+func (r *Repository) IsShallow(context.Context) (bool, error) {
+	return r.depth > 0, nil
+}
+
+// TagReachable implements git.Repository.
+func (r *Repository) TagReachable(_ context.Context, name string) (bool, error) {
+	t, ok := r.tags[name]
+	if !ok || !r.fetched(t) {
+		return false, fmt.Errorf("not a valid commit name refs/tags/%s", name)
+	}
+	return t.merged && r.inHistory(t), nil
+}
+
+// Deepen implements git.Repository.
+func (r *Repository) Deepen(_ context.Context, depth int) error {
+	if r.depth > 0 {
+		r.Shallow(r.depth + depth)
+	}
+	return nil
+}
+
+// shallowStart returns the index of the oldest commit available.
+func (r *Repository) shallowStart() int {
+	if r.depth == 0 {
+		return 0
+	}
+	return len(r.commits) - r.depth
+}
+
+// fetched returns true if the given tag is available, which in a shallow
+// clone means it points to one of the commits available.
+func (r *Repository) fetched(t tag) bool {
+	return r.allTags || r.inHistory(t)
+}
+
+// inHistory returns true if the commit the given tag points to is part of
+// the history available.
+func (r *Repository) inHistory(t tag) bool {
+	if r.depth == 0 {
+		return true
+	}
+	return t.merged && t.reachable > r.shallowStart()
+}
+
+// since returns the commits after the given tag, oldest first.
+func (r *Repository) since(name string) ([]commit, error) {
+	if name == "" {
+		return r.commits[r.shallowStart():], nil
+	}
+	t, ok := r.tags[name]
+	if !ok || !r.fetched(t) {
+		return nil, fmt.Errorf("ambiguous argument 'tags/%s..HEAD': unknown revision", name)
+	}
+	return r.commits[max(t.reachable, r.shallowStart()):], nil
+}
@@ -0,0 +1,132 @@
+package svutest
+
+import (
+	"testing"
+
+	"github.com/caarlos0/svu/v3/internal/git"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository(t *testing.T) {
+	repo := New()
+	sha, err := repo.HeadSHA(t.Context())
+	require.NoError(t, err)
+	require.Empty(t, sha)
+
+	first := repo.Commit("chore: init", "go.mod")
+	repo.Tag("v1.0.0")
+	repo.TagUnmerged("v2.0.0")
+	second := repo.Commit("feat: foo\n\nsome body", "foo/foo.go")
+	third := repo.Commit("fix: bar", "bar/bar.go", "foo/nested/go.mod")
+	require.NotEqual(t, first, second)
+
+	head, err := repo.HeadSHA(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, third, head)
+
+	tag, err := repo.DescribeTag(t.Context(), git.TagModeAll, git.TagSortSemver, "")
+	require.NoError(t, err)
+	require.Equal(t, "v2.0.0", tag)
+	tag, err = repo.DescribeTag(t.Context(), git.TagModeCurrent, git.TagSortSemver, "")
+	require.NoError(t, err)
+	require.Equal(t, "v1.0.0", tag)
+	_, err = repo.DescribeTag(t.Context(), git.TagModeCurrent, git.TagSortSemver, "api/*")
+	require.Error(t, err)
+
+	repo.Tag("v0.9.0")
+	tags, err := repo.ListTags(t.Context(), git.TagModeAll, git.TagSortDate)
+	require.NoError(t, err)
+	require.Equal(t, []string{"v0.9.0", "v2.0.0", "v1.0.0"}, tags)
+	tags, err = repo.ListTags(t.Context(), git.TagModeAll, git.TagSortTopo)
+	require.NoError(t, err)
+	require.Equal(t, []string{"v0.9.0", "v1.0.0", "v2.0.0"}, tags)
+	delete(repo.tags, "v0.9.0")
+
+	commits, err := repo.Changelog(t.Context(), "v1.0.0", nil)
+	require.NoError(t, err)
+	require.Equal(t, []git.Commit{
+		{SHA: third, Title: "fix: bar"},
+		{SHA: second, Title: "feat: foo", Body: "some body"},
+	}, commits)
+
+	commits, err = repo.Changelog(t.Context(), "v2.0.0", nil)
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+
+	count, err := repo.CountCommits(t.Context(), "")
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+
+	_, err = repo.Changelog(t.Context(), "v0.1.0", nil)
+	require.Error(t, err)
+
+	t.Run("pathspecs", func(t *testing.T) {
+		for name, tt := range map[string]struct {
+			dirs     []string
+			expected []string
+		}{
+			"directory":     {[]string{"foo"}, []string{third, second}},
+			"top":           {[]string{":(top)bar"}, []string{third}},
+			"root":          {[]string{":(top)"}, []string{third, second, first}},
+			"exclude":       {[]string{":(top)foo", ":(top,exclude)foo/nested"}, []string{second}},
+			"short exclude": {[]string{":!foo"}, []string{third, first}},
+			"no match":      {[]string{"baz"}, nil},
+		} {
+			t.Run(name, func(t *testing.T) {
+				commits, err := repo.Changelog(t.Context(), "", tt.dirs)
+				require.NoError(t, err)
+				var shas []string
+				for _, c := range commits {
+					shas = append(shas, c.SHA)
+				}
+				require.Equal(t, tt.expected, shas)
+			})
+		}
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		require.NoError(t, repo.CreateTag(t.Context(), "v1.1.0", "release", true))
+		require.Error(t, repo.CreateTag(t.Context(), "v1.1.0", "", false))
+		msg, signed := repo.TagMessage("v1.1.0")
+		require.Equal(t, "release", msg)
+		require.True(t, signed)
+
+		_, err := repo.RemoteTagExists(t.Context(), "origin", "v1.1.0")
+		require.Error(t, err)
+		repo.AddRemote("origin")
+		require.Error(t, repo.PushTag(t.Context(), "origin", "v9.9.9"))
+		require.NoError(t, repo.PushTag(t.Context(), "origin", "v1.1.0"))
+		exists, err := repo.RemoteTagExists(t.Context(), "origin", "v1.1.0")
+		require.NoError(t, err)
+		require.True(t, exists)
+	})
+
+	t.Run("shallow", func(t *testing.T) {
+		repo.Shallow(1)
+		shallow, err := repo.IsShallow(t.Context())
+		require.NoError(t, err)
+		require.True(t, shallow)
+
+		tags, err := repo.ListTags(t.Context(), git.TagModeAll, git.TagSortSemver)
+		require.NoError(t, err)
+		require.Equal(t, []string{"v1.1.0"}, tags)
+		_, err = repo.TagReachable(t.Context(), "v1.0.0")
+		require.Error(t, err)
+		count, err := repo.CountCommits(t.Context(), "")
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+
+		require.NoError(t, repo.Deepen(t.Context(), 1))
+		shallow, err = repo.IsShallow(t.Context())
+		require.NoError(t, err)
+		require.True(t, shallow)
+
+		require.NoError(t, repo.Deepen(t.Context(), 1))
+		shallow, err = repo.IsShallow(t.Context())
+		require.NoError(t, err)
+		require.False(t, shallow)
+		reachable, err := repo.TagReachable(t.Context(), "v1.0.0")
+		require.NoError(t, err)
+		require.True(t, reachable)
+	})
+}